@@ -0,0 +1,254 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aclrules
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"reflect"
+	"strings"
+)
+
+const (
+	AccessDeny  = "deny"
+	AccessList  = "list"
+	AccessRead  = "read"
+	AccessWrite = "write"
+
+	prefixSuffix = "_prefix"
+)
+
+// accessLevels orders access levels as Consul enforces them, "list" grants "read" as well
+var accessLevels = map[string]int{
+	"":          0,
+	AccessDeny:  0,
+	AccessRead:  1,
+	AccessList:  2,
+	AccessWrite: 3,
+}
+
+// Rule is a single grant of a Consul ACL policy, for example `key_prefix "app/" { policy = "read" }`
+type Rule struct {
+	// Resource is a name of Consul resource without "_prefix" suffix, for example "key" or "operator"
+	Resource string
+	// Segment is a name or a prefix of resource the rule is applied to. It is empty for resources without segments
+	Segment string
+	// Prefix is true when the rule is applied to all resources which names start with Segment
+	Prefix bool
+	// Access is a policy of the rule, one of "deny", "read", "list" or "write"
+	Access string
+}
+
+// Block returns the name of rule block as it is written in a policy, for example "key_prefix"
+func (r Rule) Block() string {
+	if r.Prefix {
+		return r.Resource + prefixSuffix
+	}
+	return r.Resource
+}
+
+func (r Rule) String() string {
+	if r.Segment == "" && !r.Prefix {
+		return fmt.Sprintf("%s = %q", r.Resource, r.Access)
+	}
+	return fmt.Sprintf("%s %q { policy = %q }", r.Block(), r.Segment, r.Access)
+}
+
+type segmentRule struct {
+	Segment    string `hcl:",key"`
+	Policy     string `hcl:"policy"`
+	Intentions string `hcl:"intentions"`
+}
+
+type policyRules struct {
+	ACL                   string         `hcl:"acl"`
+	Operator              string         `hcl:"operator"`
+	Keyring               string         `hcl:"keyring"`
+	Mesh                  string         `hcl:"mesh"`
+	Peering               string         `hcl:"peering"`
+	Agents                []*segmentRule `hcl:"agent,expand"`
+	AgentPrefixes         []*segmentRule `hcl:"agent_prefix,expand"`
+	Events                []*segmentRule `hcl:"event,expand"`
+	EventPrefixes         []*segmentRule `hcl:"event_prefix,expand"`
+	Keys                  []*segmentRule `hcl:"key,expand"`
+	KeyPrefixes           []*segmentRule `hcl:"key_prefix,expand"`
+	Nodes                 []*segmentRule `hcl:"node,expand"`
+	NodePrefixes          []*segmentRule `hcl:"node_prefix,expand"`
+	PreparedQueries       []*segmentRule `hcl:"query,expand"`
+	PreparedQueryPrefixes []*segmentRule `hcl:"query_prefix,expand"`
+	Services              []*segmentRule `hcl:"service,expand"`
+	ServicePrefixes       []*segmentRule `hcl:"service_prefix,expand"`
+	Sessions              []*segmentRule `hcl:"session,expand"`
+	SessionPrefixes       []*segmentRule `hcl:"session_prefix,expand"`
+}
+
+// knownBlocks are names of top-level blocks which are modelled by policyRules
+var knownBlocks = func() map[string]bool {
+	blocks := map[string]bool{}
+	rulesType := reflect.TypeOf(policyRules{})
+	for i := 0; i < rulesType.NumField(); i++ {
+		blocks[strings.SplitN(rulesType.Field(i).Tag.Get("hcl"), ",", 2)[0]] = true
+	}
+	return blocks
+}()
+
+// Parse converts Consul ACL policy rules in HCL or JSON format to the list of separate rules.
+// Service intentions are returned as rules of "intention" resource. Blocks which are not modelled,
+// for example "namespace" or "partition", are rejected, so rules inside them can not escape checks.
+func Parse(rules string) ([]Rule, error) {
+	file, err := hcl.Parse(rules)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse policy rules: %w", err)
+	}
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		for _, item := range list.Items {
+			if len(item.Keys) == 0 {
+				continue
+			}
+			if block, _ := item.Keys[0].Token.Value().(string); !knownBlocks[block] {
+				return nil, fmt.Errorf("unsupported block %s in policy rules", item.Keys[0].Token.Text)
+			}
+		}
+	}
+	parsed := policyRules{}
+	if err = hcl.DecodeObject(&parsed, file); err != nil {
+		return nil, fmt.Errorf("can not parse policy rules: %w", err)
+	}
+	var result []Rule
+	result = appendResourceRule(result, "acl", parsed.ACL)
+	result = appendResourceRule(result, "operator", parsed.Operator)
+	result = appendResourceRule(result, "keyring", parsed.Keyring)
+	result = appendResourceRule(result, "mesh", parsed.Mesh)
+	result = appendResourceRule(result, "peering", parsed.Peering)
+	result = appendSegmentRules(result, "agent", false, parsed.Agents)
+	result = appendSegmentRules(result, "agent", true, parsed.AgentPrefixes)
+	result = appendSegmentRules(result, "event", false, parsed.Events)
+	result = appendSegmentRules(result, "event", true, parsed.EventPrefixes)
+	result = appendSegmentRules(result, "key", false, parsed.Keys)
+	result = appendSegmentRules(result, "key", true, parsed.KeyPrefixes)
+	result = appendSegmentRules(result, "node", false, parsed.Nodes)
+	result = appendSegmentRules(result, "node", true, parsed.NodePrefixes)
+	result = appendSegmentRules(result, "query", false, parsed.PreparedQueries)
+	result = appendSegmentRules(result, "query", true, parsed.PreparedQueryPrefixes)
+	result = appendSegmentRules(result, "service", false, parsed.Services)
+	result = appendSegmentRules(result, "service", true, parsed.ServicePrefixes)
+	result = appendSegmentRules(result, "session", false, parsed.Sessions)
+	result = appendSegmentRules(result, "session", true, parsed.SessionPrefixes)
+	for _, rule := range result {
+		if _, ok := accessLevels[rule.Access]; !ok {
+			return nil, fmt.Errorf("unknown access level %q in rule %s", rule.Access, rule)
+		}
+	}
+	return result, nil
+}
+
+func appendResourceRule(result []Rule, resource string, access string) []Rule {
+	if access == "" {
+		return result
+	}
+	return append(result, Rule{Resource: resource, Access: access})
+}
+
+func appendSegmentRules(result []Rule, resource string, prefix bool, segmentRules []*segmentRule) []Rule {
+	for _, sr := range segmentRules {
+		if sr.Policy != "" {
+			result = append(result, Rule{Resource: resource, Segment: sr.Segment, Prefix: prefix, Access: sr.Policy})
+		}
+		if sr.Intentions != "" {
+			result = append(result, Rule{Resource: "intention", Segment: sr.Segment, Prefix: prefix, Access: sr.Intentions})
+		}
+	}
+	return result
+}
+
+// AccessLevel returns a comparable weight of access level, where "deny" is the lowest one and "write" is the highest one.
+// "list" is higher than "read", because it grants "read" as well.
+func AccessLevel(access string) int {
+	return accessLevels[strings.ToLower(access)]
+}
+
+// ParseBlock splits a rule block name like "key_prefix" to resource name and prefix flag
+func ParseBlock(block string) (string, bool) {
+	if strings.HasSuffix(block, prefixSuffix) {
+		return strings.TrimSuffix(block, prefixSuffix), true
+	}
+	return block, false
+}
+
+// PolicyRule is a rule together with the name of policy it comes from
+type PolicyRule struct {
+	Rule
+	Policy string
+}
+
+// Decision is the result of authorization of access to a resource
+type Decision struct {
+	Allowed bool `json:"allowed"`
+	// Rule is the deciding rule, it is nil when no rule matches and the default policy is applied
+	Rule *PolicyRule `json:"rule,omitempty"`
+}
+
+// Authorize evaluates access to the segment of resource by rules of several policies the same way as Consul does.
+// An exact rule takes precedence over prefix rules and the longest prefix takes precedence over shorter ones.
+// Among rules for the same segment "deny" wins, otherwise the most permissive rule wins.
+// Required access is "read", "list" or "write". If no rule matches, defaultAllow is returned.
+func Authorize(rules []PolicyRule, resource string, segment string, access string, defaultAllow bool) Decision {
+	var matched *PolicyRule
+	matchedLength := -1
+	matchedExact := false
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Resource != resource {
+			continue
+		}
+		exact := !rule.Prefix && rule.Segment == segment
+		if !exact && !(rule.Prefix && strings.HasPrefix(segment, rule.Segment)) {
+			continue
+		}
+		switch {
+		case matched == nil || (exact && !matchedExact) || (!exact && !matchedExact && len(rule.Segment) > matchedLength):
+			matched = rule
+		case exact == matchedExact && (exact || len(rule.Segment) == matchedLength) && takesPrecedence(rule.Access, matched.Access):
+			matched = rule
+		default:
+			continue
+		}
+		matchedExact = exact
+		matchedLength = len(rule.Segment)
+	}
+	if matched == nil {
+		return Decision{Allowed: defaultAllow}
+	}
+	return Decision{Allowed: isAccessGranted(matched.Access, access), Rule: matched}
+}
+
+// takesPrecedence returns true if access of the first rule wins over access of the second rule for the same segment
+func takesPrecedence(access string, other string) bool {
+	if access == AccessDeny {
+		return other != AccessDeny
+	}
+	if other == AccessDeny {
+		return false
+	}
+	return accessLevels[access] > accessLevels[other]
+}
+
+func isAccessGranted(granted string, required string) bool {
+	if granted == AccessDeny {
+		return false
+	}
+	return accessLevels[granted] >= accessLevels[required]
+}
@@ -0,0 +1,192 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aclrules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		want    []Rule
+		wantErr bool
+	}{
+		{name: "empty", rules: ``, want: nil},
+		{
+			name:  "resource without segment",
+			rules: `operator = "read"`,
+			want:  []Rule{{Resource: "operator", Access: AccessRead}},
+		},
+		{
+			name:  "exact and prefix segments",
+			rules: `key "app/config" { policy = "write" } key_prefix "app/" { policy = "read" }`,
+			want: []Rule{
+				{Resource: "key", Segment: "app/config", Access: AccessWrite},
+				{Resource: "key", Segment: "app/", Prefix: true, Access: AccessRead},
+			},
+		},
+		{
+			name:  "service intentions",
+			rules: `service_prefix "" { policy = "read" intentions = "deny" }`,
+			want: []Rule{
+				{Resource: "service", Prefix: true, Access: AccessRead},
+				{Resource: "intention", Prefix: true, Access: AccessDeny},
+			},
+		},
+		{
+			name:  "json",
+			rules: `{"node_prefix": {"": {"policy": "list"}}}`,
+			want:  []Rule{{Resource: "node", Prefix: true, Access: AccessList}},
+		},
+		{name: "unknown access level", rules: `acl = "admin"`, wantErr: true},
+		{name: "namespace block", rules: `namespace "x" { key_prefix "" { policy = "write" } }`, wantErr: true},
+		{name: "partition block", rules: `partition "p" { acl = "write" }`, wantErr: true},
+		{name: "unmodelled resource", rules: `identity_prefix "" { policy = "write" }`, wantErr: true},
+		{name: "unmodelled block in json", rules: `{"namespace_prefix": {"": {"acl": "write"}}}`, wantErr: true},
+		{name: "invalid syntax", rules: `key_prefix "" {`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(test.rules)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Parse succeeded, want error, rules: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Parse returned %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{name: "resource without segment", rule: Rule{Resource: "acl", Access: AccessWrite}, want: `acl = "write"`},
+		{name: "exact segment", rule: Rule{Resource: "key", Segment: "app", Access: AccessRead}, want: `key "app" { policy = "read" }`},
+		{name: "empty prefix", rule: Rule{Resource: "key", Prefix: true, Access: AccessDeny}, want: `key_prefix "" { policy = "deny" }`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.rule.String(); got != test.want {
+				t.Errorf("String returned %s, want %s", got, test.want)
+			}
+			resource, prefix := ParseBlock(test.rule.Block())
+			if resource != test.rule.Resource || prefix != test.rule.Prefix {
+				t.Errorf("ParseBlock(%q) returned %s, %t", test.rule.Block(), resource, prefix)
+			}
+		})
+	}
+}
+
+func TestAccessLevel(t *testing.T) {
+	tests := []struct {
+		lower  string
+		higher string
+	}{
+		{lower: AccessDeny, higher: AccessRead},
+		{lower: AccessRead, higher: AccessList},
+		{lower: AccessList, higher: AccessWrite},
+		{lower: "READ", higher: "List"},
+		{lower: "", higher: AccessRead},
+	}
+	for _, test := range tests {
+		t.Run(test.lower+" "+test.higher, func(t *testing.T) {
+			if AccessLevel(test.lower) >= AccessLevel(test.higher) {
+				t.Errorf("access level %q is not lower than %q", test.lower, test.higher)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	rule := func(policy, block, segment, access string) PolicyRule {
+		resource, prefix := ParseBlock(block)
+		return PolicyRule{Rule: Rule{Resource: resource, Segment: segment, Prefix: prefix, Access: access}, Policy: policy}
+	}
+	tests := []struct {
+		name         string
+		rules        []PolicyRule
+		segment      string
+		access       string
+		defaultAllow bool
+		wantAllowed  bool
+		wantPolicy   string
+	}{
+		{name: "no rules denied by default", segment: "app/config", access: AccessRead},
+		{name: "no rules allowed by default", segment: "app/config", access: AccessRead, defaultAllow: true, wantAllowed: true},
+		{
+			name:    "rule of other resource is ignored",
+			rules:   []PolicyRule{rule("service", "service_prefix", "", AccessWrite)},
+			segment: "app/config", access: AccessRead,
+		},
+		{
+			name:    "exact rule wins over longer prefix",
+			rules:   []PolicyRule{rule("exact", "key", "app/config", AccessDeny), rule("prefix", "key_prefix", "app/config", AccessWrite)},
+			segment: "app/config", access: AccessRead, wantPolicy: "exact",
+		},
+		{
+			name:    "longest prefix wins",
+			rules:   []PolicyRule{rule("short", "key_prefix", "", AccessWrite), rule("long", "key_prefix", "app/", AccessDeny)},
+			segment: "app/config", access: AccessRead, wantPolicy: "long",
+		},
+		{
+			name:    "deny wins for the same prefix",
+			rules:   []PolicyRule{rule("write", "key_prefix", "app/", AccessWrite), rule("deny", "key_prefix", "app/", AccessDeny)},
+			segment: "app/config", access: AccessRead, wantPolicy: "deny",
+		},
+		{
+			name:    "most permissive wins for the same segment",
+			rules:   []PolicyRule{rule("read", "key", "app/config", AccessRead), rule("write", "key", "app/config", AccessWrite)},
+			segment: "app/config", access: AccessWrite, wantAllowed: true, wantPolicy: "write",
+		},
+		{
+			name:    "list grants read",
+			rules:   []PolicyRule{rule("list", "key_prefix", "app/", AccessList)},
+			segment: "app/config", access: AccessRead, wantAllowed: true, wantPolicy: "list",
+		},
+		{
+			name:    "read does not grant write",
+			rules:   []PolicyRule{rule("read", "key_prefix", "", AccessRead)},
+			segment: "app/config", access: AccessWrite, defaultAllow: true, wantPolicy: "read",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decision := Authorize(test.rules, "key", test.segment, test.access, test.defaultAllow)
+			if decision.Allowed != test.wantAllowed {
+				t.Errorf("Authorize returned allowed %t, want %t", decision.Allowed, test.wantAllowed)
+			}
+			policy := ""
+			if decision.Rule != nil {
+				policy = decision.Rule.Policy
+			}
+			if policy != test.wantPolicy {
+				t.Errorf("deciding rule is from policy %q, want %q", policy, test.wantPolicy)
+			}
+		})
+	}
+}
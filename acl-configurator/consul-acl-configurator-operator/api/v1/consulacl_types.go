@@ -0,0 +1,145 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ACLJSONAnnotation keeps the JSON specification of v1alpha1 ConsulACL, so v1alpha1 clients read it back unchanged
+// and fields which are absent in v1, for example enterprise namespaces of policies, are not lost. It is used only while
+// it describes the same ACL entities as the structured specification, or if it can not be parsed and the structured
+// specification is empty.
+var ACLJSONAnnotation = GroupVersion.Group + "/acl-json"
+
+// Policy is Consul ACL policy. Its name is prefixed with the name and the namespace of custom resource.
+type Policy struct {
+	// ID is the ID of existing Consul policy, the policy is found by name if it is empty
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Rules       string   `json:"rules,omitempty"`
+	Datacenters []string `json:"datacenters,omitempty"`
+	// Frozen policy is created once and never updated afterwards
+	Frozen bool `json:"frozen,omitempty"`
+}
+
+// TemplatedPolicyVariables are variables of Consul templated policy
+type TemplatedPolicyVariables struct {
+	Name string `json:"name,omitempty"`
+}
+
+// TemplatedPolicy is Consul templated policy linked to the role
+type TemplatedPolicy struct {
+	TemplateName string                    `json:"templateName"`
+	Variables    *TemplatedPolicyVariables `json:"variables,omitempty"`
+	Datacenters  []string                  `json:"datacenters,omitempty"`
+}
+
+// Role is Consul ACL role. Its name is prefixed with the name and the namespace of custom resource.
+type Role struct {
+	// ID is the ID of existing Consul role, the role is found by name if it is empty
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// PolicyNames refers to policies of the same custom resource
+	PolicyNames []string `json:"policyNames,omitempty"`
+	// GlobalPolicyNames refers to policies managed by ClusterConsulACL resources
+	GlobalPolicyNames []string          `json:"globalPolicyNames,omitempty"`
+	TemplatedPolicies []TemplatedPolicy `json:"templatedPolicies,omitempty"`
+	// Frozen role is created once and never updated afterwards
+	Frozen bool `json:"frozen,omitempty"`
+}
+
+// BindingRule is Consul ACL binding rule for the service account of the namespace
+type BindingRule struct {
+	// ID is the ID of existing Consul binding rule
+	ID                 string `json:"id,omitempty"`
+	Description        string `json:"description,omitempty"`
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// BindType is "role" by default. For "templated-policy" BindName is the name of templated policy.
+	BindType string                    `json:"bindType,omitempty"`
+	BindName string                    `json:"bindName,omitempty"`
+	BindVars *TemplatedPolicyVariables `json:"bindVars,omitempty"`
+}
+
+// ConsulACLSpec defines the desired state of ConsulACL
+type ConsulACLSpec struct {
+	// Name is the name of ACL configuration, it is not used by the operator
+	Name string `json:"name,omitempty"`
+	// CommonReconcile is changed by the common reconcile REST endpoint to start reconciliation of all resources
+	CommonReconcile string        `json:"commonReconcile,omitempty"`
+	Policies        []Policy      `json:"policies,omitempty"`
+	Roles           []Role        `json:"roles,omitempty"`
+	BindRules       []BindingRule `json:"bindRules,omitempty"`
+}
+
+// EntityUsage is the number of Consul ACL entities of one type managed in the namespace
+type EntityUsage struct {
+	Used int `json:"used"`
+	// Limit is the quota of the namespace, zero means unlimited
+	Limit int `json:"limit,omitempty"`
+}
+
+// QuotaUsage describes usage of quotas of the namespace by Consul ACL entities of all its resources
+type QuotaUsage struct {
+	Policies     EntityUsage `json:"policies"`
+	Roles        EntityUsage `json:"roles"`
+	BindingRules EntityUsage `json:"bindingRules"`
+	Tokens       EntityUsage `json:"tokens,omitempty"`
+}
+
+// ConsulACLStatus defines the observed state of ConsulACL
+type ConsulACLStatus struct {
+	PoliciesStatus  string `json:"policiesStatus,omitempty"`
+	RolesStatus     string `json:"rolesStatus,omitempty"`
+	BindRulesStatus string `json:"bindRulesStatus,omitempty"`
+	GeneralStatus   string `json:"generalStatus,omitempty"`
+	// QuotaUsage shows the number of Consul ACL entities managed in the namespace and quotas of the namespace
+	QuotaUsage *QuotaUsage `json:"quotaUsage,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// IsEmpty returns true if the specification declares no Consul ACL entities
+func (in *ConsulACLSpec) IsEmpty() bool {
+	return len(in.Policies) == 0 && len(in.Roles) == 0 && len(in.BindRules) == 0
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+
+// ConsulACL is the Schema for the consulacls API
+type ConsulACL struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulACLSpec   `json:"spec,omitempty"`
+	Status ConsulACLStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulACLList contains a list of ConsulACL
+type ConsulACLList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulACL `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulACL{}, &ConsulACLList{})
+}
@@ -0,0 +1,243 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// aclConfig is the format of JSON specification of ConsulACL. Names of fields follow Consul API.
+type aclConfig struct {
+	Policies  []aclPolicy      `json:"policies,omitempty"`
+	Roles     []aclRole        `json:"roles,omitempty"`
+	BindRules []aclBindingRule `json:"bind_rules,omitempty"`
+}
+
+type aclPolicy struct {
+	ID          string   `json:"ID,omitempty"`
+	Name        string   `json:"Name,omitempty"`
+	Description string   `json:"Description,omitempty"`
+	Rules       string   `json:"Rules,omitempty"`
+	Datacenters []string `json:"Datacenters,omitempty"`
+	Frozen      bool     `json:"frozen,omitempty"`
+}
+
+type aclTemplatedPolicyVariables struct {
+	Name string `json:"Name,omitempty"`
+}
+
+type aclTemplatedPolicy struct {
+	TemplateName      string                       `json:"template_name"`
+	TemplateVariables *aclTemplatedPolicyVariables `json:"template_variables,omitempty"`
+	Datacenters       []string                     `json:"datacenters,omitempty"`
+}
+
+type aclRole struct {
+	ID                string               `json:"ID,omitempty"`
+	Name              string               `json:"Name,omitempty"`
+	Description       string               `json:"Description,omitempty"`
+	PolicyNames       []string             `json:"policy_names,omitempty"`
+	GlobalPolicyNames []string             `json:"global_policy_names,omitempty"`
+	TemplatedPolicies []aclTemplatedPolicy `json:"templated_policies,omitempty"`
+	Frozen            bool                 `json:"frozen,omitempty"`
+}
+
+type aclBindingRule struct {
+	ID                 string                       `json:"ID,omitempty"`
+	Description        string                       `json:"Description,omitempty"`
+	ServiceAccountName string                       `json:"ServiceAccountName,omitempty"`
+	BindType           string                       `json:"BindType,omitempty"`
+	BindName           string                       `json:"BindName,omitempty"`
+	BindVars           *aclTemplatedPolicyVariables `json:"BindVars,omitempty"`
+}
+
+// ConvertTo converts this ConsulACL to the Hub version (v1). JSON specification is kept in the annotation
+// of v1 ConsulACL, so it is converted back unchanged, and JSON specification which can not be parsed does not fail
+// reading of custom resource.
+func (src *ConsulACL) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*consulaclv1.ConsulACL)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = consulaclv1.ConsulACLStatus{
+		PoliciesStatus:  src.Status.PoliciesStatus,
+		RolesStatus:     src.Status.RolesStatus,
+		BindRulesStatus: src.Status.BindRulesStatus,
+		GeneralStatus:   src.Status.GeneralStatus,
+		Conditions:      src.Status.Conditions,
+	}
+	if usage := src.Status.QuotaUsage; usage != nil {
+		dst.Status.QuotaUsage = &consulaclv1.QuotaUsage{
+			Policies:     consulaclv1.EntityUsage(usage.Policies),
+			Roles:        consulaclv1.EntityUsage(usage.Roles),
+			BindingRules: consulaclv1.EntityUsage(usage.BindingRules),
+			Tokens:       consulaclv1.EntityUsage(usage.Tokens),
+		}
+	}
+	dst.Spec = consulaclv1.ConsulACLSpec{}
+	if src.Spec.ACL == nil {
+		return nil
+	}
+	dst.Annotations = copyAnnotations(src.Annotations)
+	dst.Annotations[consulaclv1.ACLJSONAnnotation] = src.Spec.ACL.Json
+	spec, err := src.Spec.ACL.ToSpec()
+	if err != nil {
+		spec = consulaclv1.ConsulACLSpec{Name: src.Spec.ACL.Name, CommonReconcile: src.Spec.ACL.CommonReconcile}
+	}
+	dst.Spec = spec
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version
+func (dst *ConsulACL) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*consulaclv1.ConsulACL)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = ConsulACLStatus{
+		PoliciesStatus:  src.Status.PoliciesStatus,
+		RolesStatus:     src.Status.RolesStatus,
+		BindRulesStatus: src.Status.BindRulesStatus,
+		GeneralStatus:   src.Status.GeneralStatus,
+		Conditions:      src.Status.Conditions,
+	}
+	if usage := src.Status.QuotaUsage; usage != nil {
+		dst.Status.QuotaUsage = &QuotaUsage{
+			Policies:     EntityUsage(usage.Policies),
+			Roles:        EntityUsage(usage.Roles),
+			BindingRules: EntityUsage(usage.BindingRules),
+			Tokens:       EntityUsage(usage.Tokens),
+		}
+	}
+	acl := &ACL{Name: src.Spec.Name, CommonReconcile: src.Spec.CommonReconcile}
+	if _, ok := src.Annotations[consulaclv1.ACLJSONAnnotation]; ok {
+		dst.Annotations = copyAnnotations(src.Annotations)
+		delete(dst.Annotations, consulaclv1.ACLJSONAnnotation)
+	}
+	if originalJSON, ok := OriginalACLJSON(src); ok {
+		acl.Json = originalJSON
+		dst.Spec.ACL = acl
+		return nil
+	}
+	aclJSON, err := json.Marshal(newACLConfig(src.Spec))
+	if err != nil {
+		return err
+	}
+	acl.Json = string(aclJSON)
+	dst.Spec.ACL = acl
+	return nil
+}
+
+// OriginalACLJSON returns JSON specification of v1alpha1 ConsulACL which is kept in the annotation of v1 ConsulACL,
+// if it describes the same ACL entities as the specification of v1 ConsulACL. JSON specification which can not be parsed
+// is returned if the specification of v1 ConsulACL has no ACL entities.
+func OriginalACLJSON(cr *consulaclv1.ConsulACL) (string, bool) {
+	originalJSON, ok := cr.Annotations[consulaclv1.ACLJSONAnnotation]
+	if !ok {
+		return "", false
+	}
+	spec, err := (&ACL{Json: originalJSON}).ToSpec()
+	if err != nil {
+		return originalJSON, cr.Spec.IsEmpty()
+	}
+	// empty and absent lists are equal semantically
+	return originalJSON, equality.Semantic.DeepEqual(spec.Policies, cr.Spec.Policies) &&
+		equality.Semantic.DeepEqual(spec.Roles, cr.Spec.Roles) &&
+		equality.Semantic.DeepEqual(spec.BindRules, cr.Spec.BindRules)
+}
+
+// ToSpec parses JSON specification of ConsulACL to the specification of v1 ConsulACL
+func (in *ACL) ToSpec() (consulaclv1.ConsulACLSpec, error) {
+	spec := consulaclv1.ConsulACLSpec{Name: in.Name, CommonReconcile: in.CommonReconcile}
+	config := aclConfig{}
+	if err := json.Unmarshal([]byte(in.Json), &config); err != nil {
+		return spec, err
+	}
+	for _, policy := range config.Policies {
+		spec.Policies = append(spec.Policies, consulaclv1.Policy(policy))
+	}
+	for _, role := range config.Roles {
+		converted := consulaclv1.Role{
+			ID:                role.ID,
+			Name:              role.Name,
+			Description:       role.Description,
+			PolicyNames:       role.PolicyNames,
+			GlobalPolicyNames: role.GlobalPolicyNames,
+			Frozen:            role.Frozen,
+		}
+		for _, templatedPolicy := range role.TemplatedPolicies {
+			converted.TemplatedPolicies = append(converted.TemplatedPolicies, consulaclv1.TemplatedPolicy{
+				TemplateName: templatedPolicy.TemplateName,
+				Variables:    (*consulaclv1.TemplatedPolicyVariables)(templatedPolicy.TemplateVariables),
+				Datacenters:  templatedPolicy.Datacenters,
+			})
+		}
+		spec.Roles = append(spec.Roles, converted)
+	}
+	for _, bindRule := range config.BindRules {
+		spec.BindRules = append(spec.BindRules, consulaclv1.BindingRule{
+			ID:                 bindRule.ID,
+			Description:        bindRule.Description,
+			ServiceAccountName: bindRule.ServiceAccountName,
+			BindType:           bindRule.BindType,
+			BindName:           bindRule.BindName,
+			BindVars:           (*consulaclv1.TemplatedPolicyVariables)(bindRule.BindVars),
+		})
+	}
+	return spec, nil
+}
+
+func newACLConfig(spec consulaclv1.ConsulACLSpec) aclConfig {
+	config := aclConfig{}
+	for _, policy := range spec.Policies {
+		config.Policies = append(config.Policies, aclPolicy(policy))
+	}
+	for _, role := range spec.Roles {
+		converted := aclRole{
+			ID:                role.ID,
+			Name:              role.Name,
+			Description:       role.Description,
+			PolicyNames:       role.PolicyNames,
+			GlobalPolicyNames: role.GlobalPolicyNames,
+			Frozen:            role.Frozen,
+		}
+		for _, templatedPolicy := range role.TemplatedPolicies {
+			converted.TemplatedPolicies = append(converted.TemplatedPolicies, aclTemplatedPolicy{
+				TemplateName:      templatedPolicy.TemplateName,
+				TemplateVariables: (*aclTemplatedPolicyVariables)(templatedPolicy.Variables),
+				Datacenters:       templatedPolicy.Datacenters,
+			})
+		}
+		config.Roles = append(config.Roles, converted)
+	}
+	for _, bindRule := range spec.BindRules {
+		config.BindRules = append(config.BindRules, aclBindingRule{
+			ID:                 bindRule.ID,
+			Description:        bindRule.Description,
+			ServiceAccountName: bindRule.ServiceAccountName,
+			BindType:           bindRule.BindType,
+			BindName:           bindRule.BindName,
+			BindVars:           (*aclTemplatedPolicyVariables)(bindRule.BindVars),
+		})
+	}
+	return config
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	copied := make(map[string]string, len(annotations)+1)
+	for key, value := range annotations {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,101 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+)
+
+func TestConsulACLConversionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{name: "empty", json: `{}`},
+		{
+			name: "policies roles and binding rules",
+			json: `{"policies":[{"Name":"read","Rules":"key_prefix \"\" { policy = \"read\" }","Datacenters":[]}],` +
+				`"roles":[{"Name":"app","policy_names":["read"],"templated_policies":[{"template_name":"builtin/service",` +
+				`"template_variables":{"Name":"app"}}]}],"bind_rules":[{"BindName":"app","ServiceAccountName":"app"}]}`,
+		},
+		{name: "enterprise fields", json: `{"policies":[{"Name":"read","Rules":"","Namespace":"team","Partition":"default"}]}`},
+		{name: "formatting", json: "{\n  \"policies\": [ { \"name\": \"read\" } ]\n}"},
+		{name: "invalid", json: `{"policies":`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := &ConsulACL{
+				ObjectMeta: metav1.ObjectMeta{Name: "acl", Namespace: "ns", Annotations: map[string]string{"kept": "true"}},
+				Spec:       ConsulACLSpec{ACL: &ACL{Name: "acl", Json: test.json, CommonReconcile: "1"}},
+			}
+			hub := &consulaclv1.ConsulACL{}
+			if err := src.ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo failed: %v", err)
+			}
+			if hub.Spec.Name != "acl" || hub.Spec.CommonReconcile != "1" {
+				t.Errorf("name and common reconcile are not converted: %+v", hub.Spec)
+			}
+			dst := &ConsulACL{}
+			if err := dst.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom failed: %v", err)
+			}
+			if dst.Spec.ACL.Json != test.json {
+				t.Errorf("JSON specification is changed by round trip: got %s, want %s", dst.Spec.ACL.Json, test.json)
+			}
+			if _, ok := dst.Annotations[consulaclv1.ACLJSONAnnotation]; ok || dst.Annotations["kept"] != "true" {
+				t.Errorf("unexpected annotations after round trip: %v", dst.Annotations)
+			}
+		})
+	}
+}
+
+func TestConsulACLConvertFromChangedSpec(t *testing.T) {
+	hub := &consulaclv1.ConsulACL{}
+	if err := (&ConsulACL{Spec: ConsulACLSpec{ACL: &ACL{Json: `{"policies":[{"Name":"read","Rules":"acl = \"read\""}]}`}}}).ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo failed: %v", err)
+	}
+	if len(hub.Spec.Policies) != 1 || hub.Spec.Policies[0].Rules != `acl = "read"` {
+		t.Fatalf("policies are not converted: %+v", hub.Spec.Policies)
+	}
+	hub.Spec.Policies[0].Rules = `acl = "write"`
+	if _, ok := OriginalACLJSON(hub); ok {
+		t.Errorf("JSON specification is used after the structured specification is changed")
+	}
+	dst := &ConsulACL{}
+	if err := dst.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom failed: %v", err)
+	}
+	want := `{"policies":[{"Name":"read","Rules":"acl = \"write\""}]}`
+	if dst.Spec.ACL.Json != want {
+		t.Errorf("got %s, want %s", dst.Spec.ACL.Json, want)
+	}
+}
+
+func TestOriginalACLJSONOfInvalidJSON(t *testing.T) {
+	hub := &consulaclv1.ConsulACL{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{consulaclv1.ACLJSONAnnotation: `{"policies":`},
+	}}
+	if _, ok := OriginalACLJSON(hub); !ok {
+		t.Errorf("invalid JSON specification is not used for empty structured specification")
+	}
+	hub.Spec.Policies = []consulaclv1.Policy{{Name: "read"}}
+	if _, ok := OriginalACLJSON(hub); ok {
+		t.Errorf("invalid JSON specification is used instead of structured specification")
+	}
+}
@@ -29,20 +29,40 @@ type ConsulACLSpec struct {
 	ACL *ACL `json:"acl"`
 }
 
+// EntityUsage is the number of Consul ACL entities of one type managed in the namespace
+type EntityUsage struct {
+	Used int `json:"used"`
+	// Limit is the quota of the namespace, zero means unlimited
+	Limit int `json:"limit,omitempty"`
+}
+
+// QuotaUsage describes usage of quotas of the namespace by Consul ACL entities of all its resources
+type QuotaUsage struct {
+	Policies     EntityUsage `json:"policies"`
+	Roles        EntityUsage `json:"roles"`
+	BindingRules EntityUsage `json:"bindingRules"`
+	Tokens       EntityUsage `json:"tokens,omitempty"`
+}
+
 // ConsulACLStatus defines the observed state of ConsulACL
 type ConsulACLStatus struct {
 	PoliciesStatus  string `json:"policiesStatus"`
 	RolesStatus     string `json:"rolesStatus,omitempty"`
 	BindRulesStatus string `json:"bindRulesStatus,omitempty"`
 	GeneralStatus   string `json:"generalStatus,omitempty"`
+	// QuotaUsage shows the number of Consul ACL entities managed in the namespace and quotas of the namespace
+	QuotaUsage *QuotaUsage `json:"quotaUsage,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
-//+kubebuilder:storageversion
+//+kubebuilder:deprecatedversion:warning="netcracker.com/v1alpha1 ConsulACL is deprecated, use netcracker.com/v1 ConsulACL"
 //+genclient:nonNamespaced
 
-// ConsulACL is the Schema for the consulacls API
+// ConsulACL is the Schema for the consulacls API. The ACL configuration is specified as JSON string,
+// it is converted to structured fields of v1 ConsulACL.
 type ConsulACL struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
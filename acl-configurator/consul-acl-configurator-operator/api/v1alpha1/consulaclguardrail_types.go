@@ -0,0 +1,65 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GuardrailNamespacePlaceholder is replaced with the namespace of checked ConsulACL in allowed prefixes
+const GuardrailNamespacePlaceholder = "${namespace}"
+
+// AllowedRule describes which rules of a single Consul ACL resource can be granted
+type AllowedRule struct {
+	// Resource is a rule block name, for example "key_prefix", "service" or "operator".
+	// A prefix block also allows exact rules of the same resource, for example "key_prefix" allows "key".
+	Resource string `json:"resource"`
+	// Prefixes restricts segments of rules. A segment must start with one of prefixes.
+	// "${namespace}" is replaced with the namespace of ConsulACL. Empty list allows any segment.
+	Prefixes []string `json:"prefixes,omitempty"`
+	// MaxAccess is the highest allowed access level, one of "read", "list" or "write". "list" allows "read" as well.
+	MaxAccess string `json:"maxAccess"`
+}
+
+// ConsulACLGuardrailSpec defines the desired state of ConsulACLGuardrail
+type ConsulACLGuardrailSpec struct {
+	// NamespaceSelector selects namespaces the guardrail is applied to. Empty selector selects all namespaces.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	AllowedRules      []AllowedRule         `json:"allowedRules"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ConsulACLGuardrail is the Schema for the consulaclguardrails API
+type ConsulACLGuardrail struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConsulACLGuardrailSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulACLGuardrailList contains a list of ConsulACLGuardrail
+type ConsulACLGuardrailList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulACLGuardrail `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulACLGuardrail{}, &ConsulACLGuardrailList{})
+}
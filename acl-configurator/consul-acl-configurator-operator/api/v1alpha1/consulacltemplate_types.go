@@ -0,0 +1,62 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TemplateNamespacePlaceholder is replaced with the name of namespace in ACL configuration of template
+	TemplateNamespacePlaceholder = "${namespace}"
+	// TemplateNamespaceLabelPlaceholderPrefix starts placeholders which are replaced with labels of namespace,
+	// for example "${namespace.labels.team}"
+	TemplateNamespaceLabelPlaceholderPrefix = "${namespace.labels."
+)
+
+// ConsulACLTemplateSpec defines the desired state of ConsulACLTemplate
+type ConsulACLTemplateSpec struct {
+	// NamespaceSelector selects namespaces where ConsulACL is created from the template.
+	// Empty selector selects all namespaces.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector"`
+	// ConsulACLName is the name of created ConsulACL. The name of template is used by default.
+	ConsulACLName string `json:"consulACLName,omitempty"`
+	// ACL is copied to created ConsulACL with namespace placeholders replaced
+	ACL *ACL `json:"acl"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ConsulACLTemplate is the Schema for the consulacltemplates API
+type ConsulACLTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConsulACLTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulACLTemplateList contains a list of ConsulACLTemplate
+type ConsulACLTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulACLTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulACLTemplate{}, &ConsulACLTemplateList{})
+}
@@ -0,0 +1,72 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConsulConfigEntrySpec defines the desired state of ConsulConfigEntry. Exactly one of Config and Raw must be set.
+type ConsulConfigEntrySpec struct {
+	// Kind is the kind of config entry, for example "service-defaults", "service-resolver" or "proxy-defaults"
+	//+kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+	// Name is the name of config entry, the name of ConsulConfigEntry by default
+	Name string `json:"name,omitempty"`
+	// Config contains fields of config entry as they are named in Consul API, for example "Protocol".
+	// Unknown fields are rejected.
+	//+kubebuilder:pruning:PreserveUnknownFields
+	Config *runtime.RawExtension `json:"config,omitempty"`
+	// Raw is config entry in JSON format. Names of fields are matched case-insensitively and unknown fields are ignored.
+	Raw string `json:"raw,omitempty"`
+}
+
+// ConsulConfigEntryStatus defines the observed state of ConsulConfigEntry
+type ConsulConfigEntryStatus struct {
+	// Kind and Name identify the config entry written to Consul
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name,omitempty"`
+	// ModifyIndex is the modify index of config entry after the last write
+	ModifyIndex   uint64 `json:"modifyIndex,omitempty"`
+	GeneralStatus string `json:"generalStatus,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ConsulConfigEntry is the Schema for the consulconfigentries API
+type ConsulConfigEntry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulConfigEntrySpec   `json:"spec,omitempty"`
+	Status ConsulConfigEntryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulConfigEntryList contains a list of ConsulConfigEntry
+type ConsulConfigEntryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulConfigEntry `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulConfigEntry{}, &ConsulConfigEntryList{})
+}
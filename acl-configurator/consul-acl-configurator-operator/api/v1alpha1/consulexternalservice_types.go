@@ -0,0 +1,97 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExternalEndpoint is an external node which provides the service
+type ExternalEndpoint struct {
+	// Node is the name of external node in Consul catalog
+	//+kubebuilder:validation:MinLength=1
+	Node string `json:"node"`
+	// Address is the IP address or the host name of the node
+	//+kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+	// Port is the port of the service, the port of ConsulExternalService is used by default
+	Port int `json:"port,omitempty"`
+}
+
+// ExternalServiceCheck is a health check of the service on each endpoint. Checks of external nodes are run
+// by Consul External Service Monitor. Exactly one of HTTP and TCP must be set.
+type ExternalServiceCheck struct {
+	//+kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// HTTP is the URL which is requested, "${address}" and "${port}" are replaced with the endpoint address and port
+	HTTP string `json:"http,omitempty"`
+	// TCP is the "host:port" address which is connected, "${address}" and "${port}" are replaced as well
+	TCP string `json:"tcp,omitempty"`
+	// Interval is the period of check in Go duration format, "10s" by default
+	Interval string `json:"interval,omitempty"`
+	// Timeout is the timeout of check in Go duration format, "5s" by default
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ConsulExternalServiceSpec defines the desired state of ConsulExternalService
+type ConsulExternalServiceSpec struct {
+	// Service is the name of service in Consul catalog
+	//+kubebuilder:validation:MinLength=1
+	Service   string                 `json:"service"`
+	Port      int                    `json:"port,omitempty"`
+	Tags      []string               `json:"tags,omitempty"`
+	Meta      map[string]string      `json:"meta,omitempty"`
+	Endpoints []ExternalEndpoint     `json:"endpoints"`
+	Checks    []ExternalServiceCheck `json:"checks,omitempty"`
+}
+
+// ConsulExternalServiceStatus defines the observed state of ConsulExternalService
+type ConsulExternalServiceStatus struct {
+	// Nodes are nodes which the service is registered on
+	Nodes []string `json:"nodes,omitempty"`
+	// Service is the name of registered service
+	Service string `json:"service,omitempty"`
+	// TokenAccessorID is the accessor ID of Consul token which registers the service
+	TokenAccessorID string `json:"tokenAccessorID,omitempty"`
+	GeneralStatus   string `json:"generalStatus,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ConsulExternalService is the Schema for the consulexternalservices API. It registers external nodes
+// and the service on them in Consul catalog.
+type ConsulExternalService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulExternalServiceSpec   `json:"spec,omitempty"`
+	Status ConsulExternalServiceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulExternalServiceList contains a list of ConsulExternalService
+type ConsulExternalServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulExternalService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulExternalService{}, &ConsulExternalServiceList{})
+}
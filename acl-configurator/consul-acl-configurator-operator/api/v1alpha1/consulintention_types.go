@@ -0,0 +1,74 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IntentionSource is a service which is allowed or denied to connect to the destination service
+type IntentionSource struct {
+	// Name is the name of source service. The service must be registered by the namespace of ConsulIntention.
+	//+kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Action is "allow" or "deny"
+	//+kubebuilder:validation:Enum=allow;deny
+	Action      string `json:"action"`
+	Description string `json:"description,omitempty"`
+}
+
+// ConsulIntentionSpec defines the desired state of ConsulIntention
+type ConsulIntentionSpec struct {
+	// Destination is the name of service which "service-intentions" config entry is managed
+	//+kubebuilder:validation:MinLength=1
+	Destination string            `json:"destination"`
+	Sources     []IntentionSource `json:"sources"`
+}
+
+// ConsulIntentionStatus defines the observed state of ConsulIntention
+type ConsulIntentionStatus struct {
+	// Destination is the name of service which config entry contains sources of ConsulIntention
+	Destination   string `json:"destination,omitempty"`
+	GeneralStatus string `json:"generalStatus,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ConsulIntention is the Schema for the consulintentions API. It manages sources of "service-intentions"
+// config entry of the destination service, several ConsulIntention resources can manage different sources
+// of the same destination.
+type ConsulIntention struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulIntentionSpec   `json:"spec,omitempty"`
+	Status ConsulIntentionStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulIntentionList contains a list of ConsulIntention
+type ConsulIntentionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulIntention `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulIntention{}, &ConsulIntentionList{})
+}
@@ -0,0 +1,80 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KVPair is a key declared in ConsulKV
+type KVPair struct {
+	// Key is a path relative to the prefix of ConsulKV
+	//+kubebuilder:validation:MinLength=1
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// KVSource loads keys from data of ConfigMap or Secret from the namespace of ConsulKV.
+// Exactly one of ConfigMapName and SecretName must be set.
+type KVSource struct {
+	ConfigMapName string `json:"configMapName,omitempty"`
+	SecretName    string `json:"secretName,omitempty"`
+	// Prefix is added to data keys, for example "db/" loads "url" data key as "db/url" key
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// ConsulKVSpec defines the desired state of ConsulKV
+type ConsulKVSpec struct {
+	// Prefix is a path under "<namespace>/" which keys are written to, for example "my-app/config"
+	Prefix string     `json:"prefix,omitempty"`
+	Keys   []KVPair   `json:"keys,omitempty"`
+	From   []KVSource `json:"from,omitempty"`
+	// Prune deletes keys under the prefix which are not declared, including keys which are created outside of ConsulKV
+	Prune bool `json:"prune,omitempty"`
+}
+
+// ConsulKVStatus defines the observed state of ConsulKV
+type ConsulKVStatus struct {
+	// Keys contains modify indexes of keys written by ConsulKV by their full paths
+	Keys          map[string]uint64 `json:"keys,omitempty"`
+	GeneralStatus string            `json:"generalStatus,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ConsulKV is the Schema for the consulkvs API. It writes keys under the prefix owned by its namespace.
+type ConsulKV struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulKVSpec   `json:"spec,omitempty"`
+	Status ConsulKVStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConsulKVList contains a list of ConsulKV
+type ConsulKVList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulKV `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulKV{}, &ConsulKVList{})
+}
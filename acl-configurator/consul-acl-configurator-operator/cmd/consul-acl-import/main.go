@@ -0,0 +1,455 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// consul-acl-import reads policies, roles and binding rules from Consul ACL API and writes ConsulACL manifests
+// for them, so existing Consul ACL entities can be managed by Consul ACL Configurator.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sigs.k8s.io/yaml"
+	"sort"
+	"strings"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/controllers"
+)
+
+const (
+	// defaultPattern matches names created by Consul ACL Configurator, so imported entities keep their names
+	defaultPattern = `^(?P<name>[a-z0-9][a-z0-9.-]*)_(?P<namespace>[a-z0-9][a-z0-9-]*)_(?P<entity>.+)$`
+
+	kindConsulACL        = "ConsulACL"
+	kindClusterConsulACL = "ClusterConsulACL"
+
+	adoptionMarker = "Adopted by %s %s"
+)
+
+var selectorRegexp = regexp.MustCompile(`^serviceaccount\.namespace=="([^"]*)" and serviceaccount\.name=="([^"]*)"$`)
+
+type options struct {
+	pattern          *regexp.Regexp
+	kind             string
+	defaultName      string
+	defaultNamespace string
+	authMethod       string
+	outputDir        string
+	adopt            bool
+}
+
+// group is the future custom resource with imported entities
+type group struct {
+	name      string
+	namespace string
+	config    controllers.ACLConfig
+	// policyNames and roleNames map names of Consul entities to names of entities in ACL configuration
+	policyNames map[string]string
+	roleNames   map[string]string
+}
+
+func main() {
+	patternFlag := flag.String("pattern", defaultPattern, "regular expression for names of policies and roles. "+
+		"Named groups 'name' and 'namespace' select the custom resource, named group 'entity' is the name in ACL configuration. "+
+		"Entities which names do not match are skipped")
+	opts := options{}
+	flag.StringVar(&opts.kind, "kind", kindConsulACL, "kind of custom resources, ConsulACL or ClusterConsulACL. "+
+		"Names of ClusterConsulACL entities are not prefixed, so the 'entity' group should match the whole name")
+	flag.StringVar(&opts.defaultName, "name", "imported", "name of custom resource if the pattern has no 'name' group")
+	flag.StringVar(&opts.defaultNamespace, "namespace", "", "namespace of custom resource if the pattern has no 'namespace' group")
+	flag.StringVar(&opts.authMethod, "auth-method", os.Getenv("CONSUL_AUTH_METHOD_NAME"), "Consul auth method of binding rules, binding rules are not imported if it is empty")
+	flag.StringVar(&opts.outputDir, "output-dir", "", "directory to write a manifest per custom resource, manifests are written to stdout by default")
+	flag.BoolVar(&opts.adopt, "adopt", false, "mark imported entities in Consul for adoption and keep their IDs in ACL configuration")
+	flag.Parse()
+
+	var err error
+	if opts.pattern, err = regexp.Compile(*patternFlag); err != nil {
+		exit(fmt.Errorf("pattern is invalid: %w", err))
+	}
+	if opts.kind != kindConsulACL && opts.kind != kindClusterConsulACL {
+		exit(fmt.Errorf("kind must be %s or %s", kindConsulACL, kindClusterConsulACL))
+	}
+
+	// Consul address, token and TLS settings are taken from CONSUL_HTTP_* environment variables
+	client, err := consulApi.NewClient(consulApi.DefaultConfig())
+	if err != nil {
+		exit(err)
+	}
+	groups, err := importEntities(client.ACL(), opts)
+	if err != nil {
+		exit(err)
+	}
+	if err = writeManifests(groups, opts); err != nil {
+		exit(err)
+	}
+}
+
+// importEntities groups Consul ACL entities into custom resources. With adoption, entities are marked in Consul only after
+// all of them are imported, so nothing is changed in Consul if the import fails.
+func importEntities(aclClient *consulApi.ACL, opts options) ([]*group, error) {
+	groups := map[string]*group{}
+	// policyGroups maps names of imported policies to their groups
+	policyGroups := map[string]*group{}
+	var marks []func() error
+	getGroup := func(name string, namespace string) *group {
+		key := namespace + "/" + name
+		if _, ok := groups[key]; !ok {
+			groups[key] = &group{name: name, namespace: namespace, policyNames: map[string]string{}, roleNames: map[string]string{}}
+		}
+		return groups[key]
+	}
+
+	policies, _, err := aclClient.PolicyList(&consulApi.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can not list policies: %w", err)
+	}
+	for _, entry := range policies {
+		g, entityName, ok := matchName(entry.Name, opts, getGroup)
+		if !ok {
+			continue
+		}
+		policy, _, err := aclClient.PolicyRead(entry.ID, &consulApi.QueryOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("can not read policy [%s]: %w", entry.Name, err)
+		}
+		imported := controllers.ACLPolicyAdapter{}
+		imported.Name = entityName
+		imported.Description = policy.Description
+		imported.Rules = policy.Rules
+		imported.Datacenters = policy.Datacenters
+		if opts.adopt {
+			if err = checkAdoptedName(policy.Name, entityName, g); err != nil {
+				return nil, err
+			}
+			imported.ID = policy.ID
+			imported.Description = markForAdoption(policy.Description, g, opts)
+			if imported.Description != policy.Description {
+				policy.Description = imported.Description
+				marks = append(marks, func() error {
+					if _, _, err := aclClient.PolicyUpdate(policy, &consulApi.WriteOptions{}); err != nil {
+						return fmt.Errorf("can not mark policy [%s]: %w", policy.Name, err)
+					}
+					return nil
+				})
+			}
+		}
+		policyGroups[policy.Name] = g
+		g.policyNames[policy.Name] = entityName
+		g.config.Policies = append(g.config.Policies, imported)
+	}
+
+	roles, _, err := aclClient.RoleList(&consulApi.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can not list roles: %w", err)
+	}
+	for _, role := range roles {
+		g, entityName, ok := matchName(role.Name, opts, getGroup)
+		if !ok {
+			continue
+		}
+		imported := controllers.ACLRoleAdapter{Name: entityName, Description: role.Description}
+		for _, link := range role.Policies {
+			if policyName, ok := g.policyNames[link.Name]; ok {
+				imported.PolicyNames = append(imported.PolicyNames, policyName)
+			} else if policyGroup, ok := policyGroups[link.Name]; ok {
+				// a policy of another custom resource can not be referred, only global policies of ClusterConsulACL can
+				return nil, fmt.Errorf("policy [%s] of role [%s] is imported to %s, but the role is imported to %s, "+
+					"use a pattern which groups the role and its policies together", link.Name, role.Name,
+					policyGroup.title(opts), g.title(opts))
+			} else {
+				warn("policy [%s] of role [%s] is not imported, it is referred as a global policy and must be managed by %s",
+					link.Name, role.Name, kindClusterConsulACL)
+				imported.GlobalPolicyNames = append(imported.GlobalPolicyNames, link.Name)
+			}
+		}
+		for _, templatedPolicy := range role.TemplatedPolicies {
+			imported.TemplatedPolicies = append(imported.TemplatedPolicies, controllers.ACLTemplatedPolicyAdapter{
+				TemplateName:      templatedPolicy.TemplateName,
+				TemplateVariables: templatedPolicy.TemplateVariables,
+				Datacenters:       templatedPolicy.Datacenters,
+			})
+		}
+		if len(role.ServiceIdentities) > 0 || len(role.NodeIdentities) > 0 {
+			warn("service and node identities of role [%s] are not supported and are not imported", role.Name)
+		}
+		if opts.adopt {
+			if err = checkAdoptedName(role.Name, entityName, g); err != nil {
+				return nil, err
+			}
+			imported.ID = role.ID
+			imported.Description = markForAdoption(role.Description, g, opts)
+			if imported.Description != role.Description {
+				role.Description = imported.Description
+				marks = append(marks, func() error {
+					if _, _, err := aclClient.RoleUpdate(role, &consulApi.WriteOptions{}); err != nil {
+						return fmt.Errorf("can not mark role [%s]: %w", role.Name, err)
+					}
+					return nil
+				})
+			}
+		}
+		g.roleNames[role.Name] = entityName
+		g.config.Roles = append(g.config.Roles, imported)
+	}
+
+	if opts.authMethod != "" && opts.kind == kindConsulACL {
+		bindingRuleMarks, err := importBindingRules(aclClient, opts, groups, getGroup)
+		if err != nil {
+			return nil, err
+		}
+		marks = append(marks, bindingRuleMarks...)
+	}
+	for _, mark := range marks {
+		if err = mark(); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []*group
+	for _, g := range groups {
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].namespace+"/"+result[i].name < result[j].namespace+"/"+result[j].name
+	})
+	return result, nil
+}
+
+// importBindingRules adds binding rules to groups of their roles. Binding rules to templated policies are added
+// to the group with the default name in the namespace of their selector. Marks of adopted binding rules are returned.
+func importBindingRules(aclClient *consulApi.ACL, opts options, groups map[string]*group, getGroup func(string, string) *group) (
+	[]func() error, error) {
+	bindingRules, _, err := aclClient.BindingRuleList(opts.authMethod, &consulApi.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can not list binding rules: %w", err)
+	}
+	var marks []func() error
+	for _, bindingRule := range bindingRules {
+		match := selectorRegexp.FindStringSubmatch(bindingRule.Selector)
+		if match == nil {
+			warn("selector [%s] of binding rule [%s] is not supported, the binding rule is not imported", bindingRule.Selector, bindingRule.ID)
+			continue
+		}
+		namespace, serviceAccountName := match[1], match[2]
+		imported := controllers.ACLBindingRuleAdapter{
+			Description:        bindingRule.Description,
+			ServiceAccountName: serviceAccountName,
+			BindType:           string(bindingRule.BindType),
+			BindName:           bindingRule.BindName,
+			BindVars:           bindingRule.BindVars,
+		}
+		var target *group
+		if bindingRule.BindType == consulApi.BindingRuleBindTypeTemplatedPolicy {
+			target = getGroup(opts.defaultName, namespace)
+		} else {
+			for _, g := range groups {
+				if roleName, ok := g.roleNames[bindingRule.BindName]; ok && g.namespace == namespace {
+					target = g
+					imported.BindName = roleName
+					imported.BindType = ""
+					break
+				}
+			}
+		}
+		if target == nil {
+			warn("role [%s] of binding rule [%s] is not imported in namespace [%s], the binding rule is not imported",
+				bindingRule.BindName, bindingRule.ID, namespace)
+			continue
+		}
+		if opts.adopt {
+			imported.ID = bindingRule.ID
+			imported.Description = markForAdoption(bindingRule.Description, target, opts)
+			if imported.Description != bindingRule.Description {
+				bindingRule.Description = imported.Description
+				marks = append(marks, func() error {
+					if _, _, err := aclClient.BindingRuleUpdate(bindingRule, &consulApi.WriteOptions{}); err != nil {
+						return fmt.Errorf("can not mark binding rule [%s]: %w", bindingRule.ID, err)
+					}
+					return nil
+				})
+			}
+		}
+		target.config.BindRules = append(target.config.BindRules, imported)
+	}
+	return marks, nil
+}
+
+// matchName returns the group and the name in ACL configuration for the name of Consul entity
+func matchName(name string, opts options, getGroup func(string, string) *group) (*group, string, bool) {
+	match := opts.pattern.FindStringSubmatch(name)
+	if match == nil {
+		return nil, "", false
+	}
+	crName, namespace, entityName := opts.defaultName, opts.defaultNamespace, name
+	for i, groupName := range opts.pattern.SubexpNames() {
+		switch groupName {
+		case "name":
+			crName = match[i]
+		case "namespace":
+			namespace = match[i]
+		case "entity":
+			entityName = match[i]
+		}
+	}
+	if opts.kind == kindClusterConsulACL {
+		namespace = ""
+	} else if namespace == "" {
+		warn("namespace of [%s] is not found, use the 'namespace' group of pattern or -namespace flag", name)
+		return nil, "", false
+	}
+	return getGroup(crName, namespace), entityName, true
+}
+
+// checkAdoptedName returns the error if Consul ACL Configurator would rename the adopted entity,
+// because the name built by the naming convention differs from its current name
+func checkAdoptedName(consulName string, entityName string, g *group) error {
+	if builtName := controllers.ConsulEntityName(g.name, g.namespace, entityName); builtName != consulName {
+		return fmt.Errorf("[%s] can not be adopted, because it would be renamed to [%s], "+
+			"use a pattern which keeps names or import it without adoption", consulName, builtName)
+	}
+	return nil
+}
+
+func (g *group) title(opts options) string {
+	return fmt.Sprintf("%s %s", opts.kind, strings.TrimPrefix(g.namespace+"/"+g.name, "/"))
+}
+
+func markForAdoption(description string, g *group, opts options) string {
+	marker := fmt.Sprintf(adoptionMarker, opts.kind, strings.TrimPrefix(g.namespace+"/"+g.name, "/"))
+	if strings.Contains(description, marker) {
+		return description
+	}
+	if description == "" {
+		return marker
+	}
+	return fmt.Sprintf("%s. %s", strings.TrimSuffix(description, "."), marker)
+}
+
+func writeManifests(groups []*group, opts options) error {
+	for i, g := range groups {
+		manifest, err := buildManifest(g, opts)
+		if err != nil {
+			return err
+		}
+		if opts.outputDir == "" {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			fmt.Print(string(manifest))
+			continue
+		}
+		fileName := strings.TrimPrefix(g.namespace+"_"+g.name, "_") + ".yaml"
+		if err = os.WriteFile(filepath.Join(opts.outputDir, fileName), manifest, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifest is a custom resource without status and empty metadata fields
+type manifest struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        manifestMetadata `json:"metadata"`
+	Spec            interface{}      `json:"spec"`
+}
+
+type manifestMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func buildManifest(g *group, opts options) ([]byte, error) {
+	aclJson, err := marshalACLConfig(g.config)
+	if err != nil {
+		return nil, err
+	}
+	acl := &consulacl.ACL{Name: g.name, Json: aclJson}
+	if opts.kind == kindClusterConsulACL {
+		return yaml.Marshal(&manifest{
+			TypeMeta: metav1.TypeMeta{APIVersion: consulacl.GroupVersion.String(), Kind: opts.kind},
+			Metadata: manifestMetadata{Name: g.name, Namespace: g.namespace},
+			Spec:     consulacl.ConsulACLSpec{ACL: acl},
+		})
+	}
+	// ConsulACL is written in v1 with structured fields
+	spec, err := acl.ToSpec()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&manifest{
+		TypeMeta: metav1.TypeMeta{APIVersion: consulaclv1.GroupVersion.String(), Kind: opts.kind},
+		Metadata: manifestMetadata{Name: g.name, Namespace: g.namespace},
+		Spec:     spec,
+	})
+}
+
+// marshalACLConfig writes ACL configuration without empty fields of Consul API structures
+func marshalACLConfig(config controllers.ACLConfig) (string, error) {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	var value interface{}
+	if err = json.Unmarshal(configBytes, &value); err != nil {
+		return "", err
+	}
+	configBytes, err = json.MarshalIndent(removeEmptyValues(value), "", "  ")
+	return string(configBytes), err
+}
+
+func removeEmptyValues(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = removeEmptyValues(item)
+			switch v := typed[key].(type) {
+			case nil:
+				delete(typed, key)
+			case string:
+				if v == "" {
+					delete(typed, key)
+				}
+			case float64:
+				if v == 0 {
+					delete(typed, key)
+				}
+			case bool:
+				if !v {
+					delete(typed, key)
+				}
+			}
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = removeEmptyValues(item)
+		}
+	}
+	return value
+}
+
+func warn(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
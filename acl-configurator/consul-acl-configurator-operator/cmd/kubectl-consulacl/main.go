@@ -0,0 +1,300 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// kubectl-consulacl is a kubectl plugin which shows the effective state of ConsulACL resources in Consul.
+// Install it to PATH and run "kubectl consulacl <command>".
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/controllers"
+)
+
+const usage = `Shows the effective state of ConsulACL resources in Consul and simulates access of service accounts.
+
+Usage:
+  kubectl consulacl describe NAME [-n NAMESPACE] [--cluster] [-o table|json]
+      Shows desired entities of ConsulACL (or ClusterConsulACL with --cluster), their live Consul counterparts, IDs and drift.
+  kubectl consulacl bindings SERVICEACCOUNT [-n NAMESPACE] [-o table|json]
+      Shows binding rules which match the service account.
+  kubectl consulacl can SERVICEACCOUNT ACCESS RESOURCE [SEGMENT] [-n NAMESPACE] [--default-policy deny|allow] [-o table|json]
+      Evaluates rules of ConsulACL resources bound to the service account without requests to Consul,
+      for example "kubectl consulacl can my-app write key app/config" or "kubectl consulacl can my-app write service my-app".
+
+Consul address, token and TLS settings are taken from CONSUL_HTTP_* environment variables.
+
+Flags:
+`
+
+type options struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	output        string
+	cluster       bool
+	authMethod    string
+	defaultPolicy string
+}
+
+func main() {
+	opts := options{}
+	flags := flag.NewFlagSet("kubectl consulacl", flag.ExitOnError)
+	flags.StringVar(&opts.kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	flags.StringVar(&opts.context, "context", "", "name of the kubeconfig context")
+	flags.StringVar(&opts.namespace, "n", "", "namespace, the namespace of the current context by default")
+	flags.StringVar(&opts.namespace, "namespace", "", "namespace, the namespace of the current context by default")
+	flags.StringVar(&opts.output, "o", "table", "output format, table or json")
+	flags.BoolVar(&opts.cluster, "cluster", false, "describe ClusterConsulACL instead of ConsulACL")
+	flags.StringVar(&opts.authMethod, "auth-method", os.Getenv("CONSUL_AUTH_METHOD_NAME"), "Consul auth method of binding rules")
+	flags.StringVar(&opts.defaultPolicy, "default-policy", aclrules.AccessDeny, "default policy of Consul ACL, deny or allow")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	if len(os.Args) < 3 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	args := os.Args[2:]
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
+	command, positional := os.Args[1], os.Args[2:len(os.Args)-len(args)]
+	if err := flags.Parse(args); err != nil {
+		exit(err)
+	}
+	if len(positional) == 0 || (command != "can" && len(positional) != 1) {
+		flags.Usage()
+		os.Exit(2)
+	}
+	name := positional[0]
+
+	consulClient, err := consulApi.NewClient(consulApi.DefaultConfig())
+	if err != nil {
+		exit(err)
+	}
+	switch command {
+	case "describe":
+		err = describe(consulClient.ACL(), name, opts)
+	case "bindings":
+		err = bindings(consulClient.ACL(), name, opts)
+	case "can":
+		if len(positional) < 3 || len(positional) > 4 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = can(name, positional[1:], opts)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		exit(err)
+	}
+}
+
+func newKubernetesClient(opts *options) (client.Client, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = opts.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: opts.context})
+	if opts.namespace == "" {
+		namespace, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+		opts.namespace = namespace
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	scheme := runtime.NewScheme()
+	if err = corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err = consulacl.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err = consulaclv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return client.New(restConfig, client.Options{Scheme: scheme})
+}
+
+func describe(aclClient *consulApi.ACL, name string, opts options) error {
+	kubeClient, err := newKubernetesClient(&opts)
+	if err != nil {
+		return err
+	}
+	var states []controllers.EntityState
+	var status interface{}
+	var conditions []metav1.Condition
+	if opts.cluster {
+		cr := &consulacl.ClusterConsulACL{}
+		if err = kubeClient.Get(context.Background(), types.NamespacedName{Name: name}, cr); err != nil {
+			return err
+		}
+		status, conditions = cr.Status, cr.Status.Conditions
+		states, err = controllers.InspectClusterConsulACL(aclClient, cr)
+	} else {
+		cr := &consulaclv1.ConsulACL{}
+		if err = kubeClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: opts.namespace}, cr); err != nil {
+			return err
+		}
+		status, conditions = cr.Status, cr.Status.Conditions
+		states, err = controllers.InspectConsulACL(aclClient, cr, opts.authMethod)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "json" {
+		return printJSON(map[string]interface{}{"status": status, "entities": states})
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "CONDITION\tSTATUS\tREASON\tMESSAGE")
+	for _, condition := range conditions {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "TYPE\tNAME\tCONSUL NAME\tID\tSTATE")
+	var drifts []string
+	for _, state := range states {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", state.Type, state.Name, state.ConsulName, valueOrNone(state.ID), state.State)
+		if len(state.Diff) > 0 {
+			drifts = append(drifts, fmt.Sprintf("%s %s:\n  %s", state.Type, state.ConsulName, strings.Join(state.Diff, "\n  ")))
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	if len(drifts) > 0 {
+		fmt.Printf("\nDrift (- Consul, + desired):\n%s\n", strings.Join(drifts, "\n"))
+	}
+	return nil
+}
+
+func bindings(aclClient *consulApi.ACL, serviceAccountName string, opts options) error {
+	if opts.namespace == "" {
+		if _, err := newKubernetesClient(&opts); err != nil {
+			return err
+		}
+	}
+	matched, notEvaluated, err := controllers.FindServiceAccountBindingRules(aclClient, opts.authMethod, opts.namespace, serviceAccountName)
+	if err != nil {
+		return err
+	}
+	if opts.output == "json" {
+		return printJSON(map[string]interface{}{"matched": matched, "notEvaluated": notEvaluated})
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tAUTH METHOD\tBIND TYPE\tBIND NAME\tDESCRIPTION")
+	for _, rule := range matched {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", rule.ID, rule.AuthMethod, rule.BindType, rule.BindName, valueOrNone(rule.Description))
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	if len(notEvaluated) > 0 {
+		fmt.Printf("\n%d binding rules have selectors which are not evaluated:\n", len(notEvaluated))
+		for _, rule := range notEvaluated {
+			fmt.Printf("  %s %s %s: %s\n", rule.ID, rule.BindType, rule.BindName, rule.Selector)
+		}
+	}
+	return nil
+}
+
+func can(serviceAccountName string, args []string, opts options) error {
+	access, resource, segment := args[0], strings.TrimSuffix(args[1], "_prefix"), ""
+	if len(args) > 2 {
+		segment = args[2]
+	}
+	if access != aclrules.AccessRead && access != aclrules.AccessList && access != aclrules.AccessWrite {
+		return fmt.Errorf("access must be read, list or write, got [%s]", access)
+	}
+	if opts.defaultPolicy != aclrules.AccessDeny && opts.defaultPolicy != "allow" {
+		return fmt.Errorf("default policy must be deny or allow, got [%s]", opts.defaultPolicy)
+	}
+	kubeClient, err := newKubernetesClient(&opts)
+	if err != nil {
+		return err
+	}
+	serviceAccount := &corev1.ServiceAccount{}
+	if err = kubeClient.Get(context.Background(), types.NamespacedName{Name: serviceAccountName, Namespace: opts.namespace}, serviceAccount); err != nil {
+		return err
+	}
+	simulation, err := controllers.SimulateServiceAccountAccess(context.Background(), kubeClient, serviceAccount,
+		resource, segment, access, opts.defaultPolicy == "allow")
+	if err != nil {
+		return err
+	}
+	if opts.output == "json" {
+		return printJSON(simulation)
+	}
+	answer := "no"
+	if simulation.Allowed {
+		answer = "yes"
+	}
+	fmt.Println(answer)
+	if simulation.Rule != nil {
+		fmt.Printf("Deciding rule: %s (policy %s)\n", simulation.Rule.Rule, simulation.Rule.Policy)
+	} else {
+		fmt.Printf("No rule matches, the default policy %q is applied\n", opts.defaultPolicy)
+	}
+	fmt.Printf("Roles: %s\n", valueOrNone(strings.Join(simulation.Roles, ", ")))
+	fmt.Printf("Policies: %s\n", valueOrNone(strings.Join(simulation.Policies, ", ")))
+	if len(simulation.NotEvaluated) > 0 {
+		fmt.Println("Not evaluated, the answer may differ in Consul:")
+		for _, reason := range simulation.NotEvaluated {
+			fmt.Printf("  %s\n", reason)
+		}
+	}
+	return nil
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
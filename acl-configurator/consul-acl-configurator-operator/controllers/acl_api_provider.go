@@ -17,29 +17,71 @@ package controllers
 import (
 	"fmt"
 	consulApi "github.com/hashicorp/consul/api"
+	"golang.org/x/time/rate"
+	"net/http"
 	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 const (
 	tlsCaCertPath = "/consul/tls/ca/tls.crt"
+
+	consulTokenHeader = "X-Consul-Token"
+
+	defaultClientQPS   = 20
+	defaultClientBurst = 40
 )
 
+var clientQPS = getFloatEnv("CONSUL_CLIENT_QPS", defaultClientQPS)
+var clientBurst = getIntEnv("CONSUL_CLIENT_BURST", defaultClientBurst)
+
+// clientRateLimiters holds one limiter per Consul cluster address,
+// so all clients for the same cluster share the same request budget.
+var clientRateLimiters = map[string]*rate.Limiter{}
+var clientRateLimitersMutex sync.Mutex
+
+// consulToken holds the token for requests to Consul. It is replaced when the operator token is rotated.
+var consulToken atomic.Value
+
+// ACLPolicyAdapter is Consul policy with settings of the operator
+type ACLPolicyAdapter struct {
+	consulApi.ACLPolicy
+	// Frozen policy is created once and never updated afterwards
+	Frozen bool `json:"frozen,omitempty"`
+}
+
 type ACLRoleAdapter struct {
 	ID          string   `json:"ID,omitempty"`
 	Name        string   `json:"Name,omitempty"`
 	Description string   `json:"Description,omitempty"`
 	PolicyNames []string `json:"policy_names,omitempty"`
+	// GlobalPolicyNames refers to policies managed by ClusterConsulACL resources
+	GlobalPolicyNames []string                    `json:"global_policy_names,omitempty"`
+	TemplatedPolicies []ACLTemplatedPolicyAdapter `json:"templated_policies,omitempty"`
+	// Frozen role is created once and never updated afterwards
+	Frozen bool `json:"frozen,omitempty"`
+}
+
+type ACLTemplatedPolicyAdapter struct {
+	TemplateName      string                                 `json:"template_name"`
+	TemplateVariables *consulApi.ACLTemplatedPolicyVariables `json:"template_variables,omitempty"`
+	Datacenters       []string                               `json:"datacenters,omitempty"`
 }
 
 type ACLBindingRuleAdapter struct {
 	ID                 string
 	Description        string
 	ServiceAccountName string
-	BindName           string
+	// BindType is "role" by default. For "templated-policy" BindName is the name of templated policy.
+	BindType string
+	BindName string
+	BindVars *consulApi.ACLTemplatedPolicyVariables
 }
 
 type ACLConfig struct {
-	Policies  []consulApi.ACLPolicy   `json:"policies,omitempty"`
+	Policies  []ACLPolicyAdapter      `json:"policies,omitempty"`
 	Roles     []ACLRoleAdapter        `json:"roles,omitempty"`
 	BindRules []ACLBindingRuleAdapter `json:"bind_rules,omitempty"`
 }
@@ -75,17 +117,90 @@ func (sh StatusHolder) GetStatus() string {
 	return resString
 }
 
-func makeAclClient() *consulApi.ACL {
+// SetupConsulClient creates the Consul client which is used by controllers. It must be called before controllers are started.
+func SetupConsulClient() error {
+	client, err := makeConsulClient()
+	if err != nil {
+		return err
+	}
+	consulClient = client
+	aclClient = client.ACL()
+	return nil
+}
+
+func makeConsulClient() (*consulApi.Client, error) {
 	consulConfig := consulApi.DefaultConfig()
 	consulConfig.Address = fmt.Sprintf("%s:%s", ConsulClientService, ConsulClientPort)
 	consulConfig.Scheme = ConsulClientScheme
-	if _, err := os.Stat(tlsCaCertPath); err == nil {
-		consulConfig.TLSConfig.CAFile = tlsCaCertPath
+	consulConfig.TLSConfig = getConsulTLSConfig()
+	setConsulToken(bootstrapToken)
+	transport, err := newReloadingTransport(consulConfig.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("can not create a Consul HTTP client: %w", err)
+	}
+	consulConfig.HttpClient = &http.Client{
+		Transport: &consulTransport{
+			limiter:   getClientRateLimiter(consulConfig.Address),
+			transport: transport,
+		},
 	}
-	consulConfig.Token = bootstrapToken
 	client, err := consulApi.NewClient(consulConfig)
 	if err != nil {
-		log.Error(err, "Can not create a Consul client configuration")
+		return nil, fmt.Errorf("can not create a Consul client configuration: %w", err)
+	}
+	return client, nil
+}
+
+// consulTransport delays requests to Consul when the rate limit of the Consul cluster is exceeded
+// and authorizes requests with the current token unless a request has its own token
+type consulTransport struct {
+	limiter   *rate.Limiter
+	transport http.RoundTripper
+}
+
+func (t *consulTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(request.Context()); err != nil {
+		return nil, err
+	}
+	if token := getConsulToken(); token != "" && request.Header.Get(consulTokenHeader) == "" {
+		request = request.Clone(request.Context())
+		request.Header.Set(consulTokenHeader, token)
+	}
+	return t.transport.RoundTrip(request)
+}
+
+func setConsulToken(token string) {
+	consulToken.Store(token)
+}
+
+func getConsulToken() string {
+	token, _ := consulToken.Load().(string)
+	return token
+}
+
+func getClientRateLimiter(address string) *rate.Limiter {
+	clientRateLimitersMutex.Lock()
+	defer clientRateLimitersMutex.Unlock()
+	limiter, ok := clientRateLimiters[address]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(clientQPS), clientBurst)
+		clientRateLimiters[address] = limiter
+	}
+	return limiter
+}
+
+func getFloatEnv(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func getIntEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
 	}
-	return client.ACL()
+	return value
 }
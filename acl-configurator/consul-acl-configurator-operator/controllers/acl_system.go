@@ -0,0 +1,201 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	conditionACLSystemReady = "ConsulACLSystemReady"
+	reasonACLSystemReady    = "Bootstrapped"
+	reasonNotBootstrapped   = "NotBootstrapped"
+	reasonTokenInvalid      = "TokenInvalid"
+	reasonConsulUnavailable = "ConsulUnavailable"
+
+	errNotBootstrapped          = "ACL system must be bootstrapped"
+	errBootstrapNotAllowed      = "ACL bootstrap no longer allowed"
+	bootstrapTokenSecretKey     = "token"
+	bootstrapAccessorSecretKey  = "accessorID"
+	defaultBootstrapSecretName  = "consul-acl-configurator-bootstrap-token"
+	aclSystemStatusCacheTimeout = 10 * time.Second
+)
+
+// ACLSystemStatus describes whether the operator can manage Consul ACLs
+type ACLSystemStatus struct {
+	Ready     bool      `json:"ready"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+var aclSystemStatus ACLSystemStatus
+var aclSystemStatusMutex sync.Mutex
+
+// CheckACLSystem returns the status of Consul ACL system. The result of the last check is cached for a short time,
+// so it can be called on each reconcile.
+func CheckACLSystem() ACLSystemStatus {
+	aclSystemStatusMutex.Lock()
+	defer aclSystemStatusMutex.Unlock()
+	if time.Since(aclSystemStatus.CheckedAt) < aclSystemStatusCacheTimeout {
+		return aclSystemStatus
+	}
+	aclSystemStatus = ACLSystemStatus{Ready: true, Reason: reasonACLSystemReady, Message: "Consul ACL system is bootstrapped", CheckedAt: time.Now()}
+	_, _, err := aclClient.TokenReadSelf(&consulApi.QueryOptions{})
+	switch {
+	case err == nil:
+	case isErrNotBootstrapped(err):
+		aclSystemStatus.Ready = false
+		aclSystemStatus.Reason = reasonNotBootstrapped
+		aclSystemStatus.Message = "Consul ACL system is not bootstrapped"
+	case isErrNotFound(err):
+		aclSystemStatus.Ready = false
+		aclSystemStatus.Reason = reasonTokenInvalid
+		aclSystemStatus.Message = fmt.Sprintf("Consul token of the operator is not valid: %s", err)
+	default:
+		aclSystemStatus.Ready = false
+		aclSystemStatus.Reason = reasonConsulUnavailable
+		aclSystemStatus.Message = fmt.Sprintf("Consul ACL API is not available: %s", err)
+	}
+	return aclSystemStatus
+}
+
+func resetACLSystemStatus() {
+	aclSystemStatusMutex.Lock()
+	defer aclSystemStatusMutex.Unlock()
+	aclSystemStatus = ACLSystemStatus{}
+}
+
+func newACLSystemCondition(status ACLSystemStatus, generation int64) metav1.Condition {
+	conditionStatus := metav1.ConditionTrue
+	if !status.Ready {
+		conditionStatus = metav1.ConditionFalse
+	}
+	return metav1.Condition{
+		Type:               conditionACLSystemReady,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             status.Reason,
+		Message:            status.Message,
+	}
+}
+
+func isErrNotBootstrapped(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errNotBootstrapped)
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update
+
+// ACLSystemBootstrapper bootstraps Consul ACL system if it is not bootstrapped yet
+// and stores the management token in Kubernetes secret
+type ACLSystemBootstrapper struct {
+	Client     client.Client
+	Namespace  string
+	SecretName string
+}
+
+func NewACLSystemBootstrapper(client client.Client, namespace string) *ACLSystemBootstrapper {
+	secretName := os.Getenv("ACL_BOOTSTRAP_SECRET_NAME")
+	if secretName == "" {
+		secretName = defaultBootstrapSecretName
+	}
+	return &ACLSystemBootstrapper{
+		Client:     client,
+		Namespace:  namespace,
+		SecretName: secretName,
+	}
+}
+
+// Bootstrap uses the management token from secret if it exists, otherwise bootstraps Consul ACL system
+// when it is not bootstrapped. It returns false if Consul is not available yet and bootstrap should be retried.
+func (b *ACLSystemBootstrapper) Bootstrap(ctx context.Context) (bool, error) {
+	secret := &corev1.Secret{}
+	err := b.Client.Get(ctx, types.NamespacedName{Name: b.SecretName, Namespace: b.Namespace}, secret)
+	if err == nil {
+		useBootstrapToken(string(secret.Data[bootstrapTokenSecretKey]))
+	} else if !errors.IsNotFound(err) {
+		return false, fmt.Errorf("can not read secret [%s]: %w", b.SecretName, err)
+	}
+
+	resetACLSystemStatus()
+	status := CheckACLSystem()
+	switch status.Reason {
+	case reasonACLSystemReady:
+		return true, nil
+	case reasonConsulUnavailable:
+		log.Info(fmt.Sprintf("Waiting for Consul to bootstrap ACL system: %s", status.Message))
+		return false, nil
+	case reasonTokenInvalid:
+		return false, fmt.Errorf("consul ACL system is bootstrapped, but the bootstrap token is not valid: %s", status.Message)
+	}
+
+	log.Info("Consul ACL system is not bootstrapped, bootstrapping it")
+	// the management token is stored before bootstrap, so it is not lost if the secret can not be saved
+	if secret.Data[bootstrapTokenSecretKey] == nil {
+		secretID, err := uuid.NewRandom()
+		if err != nil {
+			return false, err
+		}
+		exists := secret.Name != ""
+		secret.Name, secret.Namespace, secret.Type = b.SecretName, b.Namespace, corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{bootstrapTokenSecretKey: []byte(secretID.String())}
+		if exists {
+			err = b.Client.Update(ctx, secret)
+		} else {
+			err = b.Client.Create(ctx, secret)
+		}
+		if errors.IsAlreadyExists(err) || errors.IsConflict(err) {
+			log.Info("Consul management token is saved by another replica, retrying bootstrap")
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("can not save Consul management token to secret [%s]: %w", b.SecretName, err)
+		}
+	}
+	token, _, err := aclClient.BootstrapWithToken(string(secret.Data[bootstrapTokenSecretKey]))
+	if err != nil {
+		if strings.Contains(err.Error(), errBootstrapNotAllowed) {
+			return false, fmt.Errorf("consul ACL system is already bootstrapped by someone else: %w", err)
+		}
+		return false, fmt.Errorf("can not bootstrap Consul ACL system: %w", err)
+	}
+	secret.Data[bootstrapAccessorSecretKey] = []byte(token.AccessorID)
+	if err = b.Client.Update(ctx, secret); err != nil {
+		// the accessor ID is informational, the management token is already stored
+		log.Error(err, fmt.Sprintf("Can not save accessor ID [%s] of Consul management token to secret [%s]",
+			token.AccessorID, b.SecretName))
+	}
+	useBootstrapToken(token.SecretID)
+	resetACLSystemStatus()
+	log.Info(fmt.Sprintf("Consul ACL system is bootstrapped, the management token is stored in secret [%s]", b.SecretName))
+	return true, nil
+}
+
+func useBootstrapToken(token string) {
+	bootstrapToken = token
+	setConsulToken(token)
+}
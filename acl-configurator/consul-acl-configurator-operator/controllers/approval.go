@@ -0,0 +1,201 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	conditionPendingApproval = "PendingApproval"
+	reasonApprovalRequired   = "PrivilegedRulesNotApproved"
+	reasonApproved           = "PrivilegedRulesApproved"
+	reasonNoPrivilegedRules  = "NoPrivilegedRules"
+	reasonNoApprovalKey      = "ApprovalKeyNotConfigured"
+)
+
+var approvalAnnotation = consulacl.GroupVersion.Group + "/approval"
+
+// privilegedRulePatterns contains regular expressions which are matched against rules in the canonical form,
+// for example `acl = "write"` or `service_prefix "" { policy = "write" }`. Empty list disables approvals.
+var privilegedRulePatterns = compilePrivilegedRulePatterns(os.Getenv("PRIVILEGED_RULE_PATTERNS"))
+
+// approvalKey is used to sign spec hashes, so only holders of the key can approve privileged rules.
+// Privileged rules can not be approved without the key.
+var approvalKey = os.Getenv("APPROVAL_KEY")
+
+func compilePrivilegedRulePatterns(value string) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, pattern := range strings.Split(value, "\n") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("Privileged rule pattern [%s] is skipped", pattern))
+			continue
+		}
+		patterns = append(patterns, compiled)
+	}
+	return patterns
+}
+
+// findPrivilegedPolicies returns the first privileged rule for each policy which requires approval,
+// or the reason why rules of the policy can not be checked
+func findPrivilegedPolicies(policies []ACLPolicyAdapter) map[string]string {
+	privilegedPolicies := map[string]string{}
+	if len(privilegedRulePatterns) == 0 {
+		return privilegedPolicies
+	}
+	for _, policy := range policies {
+		rules, err := aclrules.Parse(policy.Rules)
+		if err != nil {
+			// rules which can not be checked require approval as well
+			privilegedPolicies[policy.Name] = err.Error()
+			continue
+		}
+		for _, rule := range rules {
+			if isPrivilegedRule(rule) {
+				privilegedPolicies[policy.Name] = rule.String()
+				break
+			}
+		}
+	}
+	return privilegedPolicies
+}
+
+func isPrivilegedRule(rule aclrules.Rule) bool {
+	for _, pattern := range privilegedRulePatterns {
+		if pattern.MatchString(rule.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// getSpecHash returns SHA-256 hash of the namespace, the name and ACL entities of custom resource specification,
+// so an approval can not be copied to another custom resource. Other fields, for example commonReconcile, do not affect
+// approval. Empty lists are hashed as absent ones, so the hash does not depend on the version of custom resource.
+func getSpecHash(cr metav1.Object, aclConfig *ACLConfig) (string, error) {
+	specBytes, err := json.Marshal(struct {
+		Namespace string     `json:"namespace"`
+		Name      string     `json:"name"`
+		ACL       *ACLConfig `json:"acl"`
+	}{cr.GetNamespace(), cr.GetName(), canonicalACLConfig(aclConfig)})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(specBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func canonicalACLConfig(aclConfig *ACLConfig) *ACLConfig {
+	canonical := &ACLConfig{}
+	for _, policy := range aclConfig.Policies {
+		policy.Datacenters = nilIfEmpty(policy.Datacenters)
+		canonical.Policies = append(canonical.Policies, policy)
+	}
+	for _, role := range aclConfig.Roles {
+		role.PolicyNames = nilIfEmpty(role.PolicyNames)
+		role.GlobalPolicyNames = nilIfEmpty(role.GlobalPolicyNames)
+		var templatedPolicies []ACLTemplatedPolicyAdapter
+		for _, templatedPolicy := range role.TemplatedPolicies {
+			templatedPolicy.Datacenters = nilIfEmpty(templatedPolicy.Datacenters)
+			templatedPolicies = append(templatedPolicies, templatedPolicy)
+		}
+		role.TemplatedPolicies = templatedPolicies
+		canonical.Roles = append(canonical.Roles, role)
+	}
+	canonical.BindRules = aclConfig.BindRules
+	return canonical
+}
+
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// signSpecHash returns the value of approval annotation which approves the spec hash
+func signSpecHash(specHash string) string {
+	mac := hmac.New(sha256.New, []byte(approvalKey))
+	mac.Write([]byte(specHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isApproved returns true if the approval annotation of custom resource is the signed spec hash
+func isApproved(cr metav1.Object, specHash string) bool {
+	approval := cr.GetAnnotations()[approvalAnnotation]
+	return approvalKey != "" && approval != "" && hmac.Equal([]byte(approval), []byte(signSpecHash(specHash)))
+}
+
+// checkApproval returns privileged policies which are not approved yet and PendingApproval condition.
+// Policies are the policies of ACL configuration which are going to be applied.
+func checkApproval(cr *consulaclv1.ConsulACL, aclConfig *ACLConfig, policies []ACLPolicyAdapter) (map[string]string, metav1.Condition, error) {
+	condition := metav1.Condition{
+		Type:               conditionPendingApproval,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cr.Generation,
+		Reason:             reasonNoPrivilegedRules,
+		Message:            "There are no privileged rules",
+	}
+	privilegedPolicies := findPrivilegedPolicies(policies)
+	if len(privilegedPolicies) == 0 {
+		return nil, condition, nil
+	}
+	var messages []string
+	pendingPolicies := map[string]string{}
+	for policyName, rule := range privilegedPolicies {
+		pendingPolicies[policyName] = fmt.Sprintf("pending approval of privileged rule '%s'", rule)
+		messages = append(messages, fmt.Sprintf("policy [%s]: %s", policyName, rule))
+	}
+	sort.Strings(messages)
+	condition.Status = metav1.ConditionTrue
+	if approvalKey == "" {
+		condition.Reason = reasonNoApprovalKey
+		condition.Message = fmt.Sprintf("Privileged rules can not be approved, because the approval key is not configured: %s",
+			strings.Join(messages, "; "))
+		return pendingPolicies, condition, nil
+	}
+	specHash, err := getSpecHash(cr, aclConfig)
+	if err != nil {
+		return nil, condition, err
+	}
+	if isApproved(cr, specHash) {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reasonApproved
+		condition.Message = fmt.Sprintf("Privileged rules are approved for spec hash %s", specHash)
+		return nil, condition, nil
+	}
+	condition.Reason = reasonApprovalRequired
+	condition.Message = fmt.Sprintf("Privileged rules require the %s annotation signed for spec hash %s: %s",
+		approvalAnnotation, specHash, strings.Join(messages, "; "))
+	return pendingPolicies, condition, nil
+}
@@ -0,0 +1,338 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	auditEntityPolicy      = "policy"
+	auditEntityRole        = "role"
+	auditEntityBindingRule = "binding-rule"
+	auditEntityIntentions  = "service-intentions"
+	auditEntityConfigEntry = "config-entry"
+	auditEntityKey         = "key"
+
+	auditConfigMapKey               = "audit.jsonl"
+	defaultAuditConfigMapMaxEntries = 200
+)
+
+// auditSource identifies the custom resource which caused changes of Consul ACL entities
+type auditSource struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	Generation int64  `json:"generation"`
+	// FieldManager is the name of field manager which made the last change of the custom resource specification,
+	// for example "kubectl-client-side-apply". It is chosen by the client, so it is not an identity of the authenticated user.
+	FieldManager string `json:"fieldManager,omitempty"`
+}
+
+// AuditEntry describes a single change of Consul ACL entity made by the operator
+type AuditEntry struct {
+	Timestamp  time.Time   `json:"timestamp"`
+	Action     string      `json:"action"`
+	EntityType string      `json:"entityType"`
+	EntityName string      `json:"entityName"`
+	Resource   auditSource `json:"resource"`
+	Diff       []string    `json:"diff,omitempty"`
+}
+
+type auditSink interface {
+	Write(ctx context.Context, entry AuditEntry) error
+}
+
+var auditSinks []auditSink
+
+// SetupAudit configures audit sinks from environment variables. Audit is disabled if no sink is configured.
+func SetupAudit(client client.Client, namespace string) error {
+	auditSinks = nil
+	if path := os.Getenv("AUDIT_LOG_FILE"); path != "" {
+		sink, err := newJSONLinesSink(path)
+		if err != nil {
+			return err
+		}
+		auditSinks = append(auditSinks, sink)
+	}
+	if name := os.Getenv("AUDIT_CONFIGMAP_NAME"); name != "" {
+		auditSinks = append(auditSinks, &configMapSink{
+			client:     client,
+			namespace:  namespace,
+			name:       name,
+			maxEntries: getIntEnv("AUDIT_CONFIGMAP_MAX_ENTRIES", defaultAuditConfigMapMaxEntries),
+		})
+	}
+	return nil
+}
+
+func newAuditSource(cr client.Object) *auditSource {
+	return &auditSource{
+		Name:         cr.GetName(),
+		Namespace:    cr.GetNamespace(),
+		Generation:   cr.GetGeneration(),
+		FieldManager: getLastSpecManager(cr.GetManagedFields(), getSpecFieldPaths(cr)),
+	}
+}
+
+// getSpecFieldPaths returns paths of specification fields in managed fields. Service accounts are specified by annotations.
+func getSpecFieldPaths(cr client.Object) [][]string {
+	if _, ok := cr.(*corev1.ServiceAccount); ok {
+		return [][]string{
+			{"f:metadata", "f:annotations", "f:" + serviceAccountRolesAnnotation},
+			{"f:metadata", "f:annotations", "f:" + serviceAccountRulesAnnotation},
+		}
+	}
+	return [][]string{{"f:spec"}}
+}
+
+// getLastSpecManager returns the manager of the latest change of specification fields. Changes of status and metadata,
+// for example finalizers added by the operator, are skipped.
+func getLastSpecManager(managedFields []metav1.ManagedFieldsEntry, specPaths [][]string) string {
+	var manager string
+	var changedAt time.Time
+	for _, entry := range managedFields {
+		if entry.Subresource != "" || entry.Time == nil || !touchesFields(entry.FieldsV1, specPaths) {
+			continue
+		}
+		if manager == "" || entry.Time.After(changedAt) {
+			manager = entry.Manager
+			changedAt = entry.Time.Time
+		}
+	}
+	return manager
+}
+
+// touchesFields returns true if managed fields contain one of the paths
+func touchesFields(fields *metav1.FieldsV1, paths [][]string) bool {
+	if fields == nil {
+		return false
+	}
+	root := map[string]interface{}{}
+	if err := json.Unmarshal(fields.Raw, &root); err != nil {
+		return false
+	}
+	for _, path := range paths {
+		current := root
+		found := true
+		for _, key := range path {
+			next, ok := current[key].(map[string]interface{})
+			if !ok {
+				found = false
+				break
+			}
+			current = next
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func isAuditEnabled(source *auditSource) bool {
+	return source != nil && len(auditSinks) > 0
+}
+
+// recordAudit writes audit entry to all sinks. Entries without source and updates without changes are skipped.
+func recordAudit(source *auditSource, action string, entityType string, entityName string, oldLines []string, newLines []string) {
+	if !isAuditEnabled(source) {
+		return
+	}
+	diff := diffLines(oldLines, newLines)
+	if action == "update" && len(diff) == 0 {
+		return
+	}
+	entry := AuditEntry{
+		Timestamp:  time.Now().UTC(),
+		Action:     action,
+		EntityType: entityType,
+		EntityName: entityName,
+		Resource:   *source,
+		Diff:       diff,
+	}
+	for _, sink := range auditSinks {
+		if err := sink.Write(context.Background(), entry); err != nil {
+			log.Error(err, fmt.Sprintf("Can not write audit entry for %s [%s]", entityType, entityName))
+		}
+	}
+}
+
+// diffLines returns removed lines with "-" prefix and added lines with "+" prefix
+func diffLines(oldLines []string, newLines []string) []string {
+	oldSet := map[string]bool{}
+	for _, line := range oldLines {
+		oldSet[line] = true
+	}
+	newSet := map[string]bool{}
+	for _, line := range newLines {
+		newSet[line] = true
+	}
+	var diff []string
+	for _, line := range oldLines {
+		if !newSet[line] {
+			diff = append(diff, "-"+line)
+		}
+	}
+	for _, line := range newLines {
+		if !oldSet[line] {
+			diff = append(diff, "+"+line)
+		}
+	}
+	return diff
+}
+
+// policyAuditLines returns policy rules in the canonical form, so formatting changes are not reported as changes
+func policyAuditLines(policy *consulApi.ACLPolicy) []string {
+	if policy == nil {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("description = %q", policy.Description)}
+	rules, err := aclrules.Parse(policy.Rules)
+	if err != nil {
+		for _, line := range strings.Split(policy.Rules, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	for _, rule := range rules {
+		lines = append(lines, rule.String())
+	}
+	sort.Strings(lines[1:])
+	return lines
+}
+
+func roleAuditLines(role *consulApi.ACLRole) []string {
+	if role == nil {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("description = %q", role.Description)}
+	for _, link := range role.Policies {
+		lines = append(lines, fmt.Sprintf("policy = %q", link.Name))
+	}
+	for _, templatedPolicy := range role.TemplatedPolicies {
+		lines = append(lines, formatTemplatedPolicy(templatedPolicy.TemplateName, templatedPolicy.TemplateVariables))
+	}
+	sort.Strings(lines[1:])
+	return lines
+}
+
+func bindingRuleAuditLines(rule *consulApi.ACLBindingRule) []string {
+	if rule == nil {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("description = %q", rule.Description),
+		fmt.Sprintf("auth_method = %q", rule.AuthMethod),
+		fmt.Sprintf("selector = %q", rule.Selector),
+		fmt.Sprintf("bind_type = %q", rule.BindType),
+		fmt.Sprintf("bind_name = %q", rule.BindName),
+	}
+	if rule.BindVars != nil {
+		lines = append(lines, fmt.Sprintf("bind_vars = { name = %q }", rule.BindVars.Name))
+	}
+	return lines
+}
+
+// jsonLinesSink appends audit entries as JSON lines to a file or to the standard output
+type jsonLinesSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func newJSONLinesSink(path string) (*jsonLinesSink, error) {
+	if path == "stdout" {
+		return &jsonLinesSink{file: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("can not open audit log file [%s]: %w", path, err)
+	}
+	return &jsonLinesSink{file: file}, nil
+}
+
+func (s *jsonLinesSink) Write(_ context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// configMapSink keeps the latest audit entries as JSON lines in a ConfigMap
+type configMapSink struct {
+	mutex      sync.Mutex
+	client     client.Client
+	namespace  string
+	name       string
+	maxEntries int
+}
+
+func (s *configMapSink) Write(ctx context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap := &corev1.ConfigMap{}
+		err := s.client.Get(ctx, types.NamespacedName{Name: s.name, Namespace: s.namespace}, configMap)
+		if errors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+				Data:       map[string]string{auditConfigMapKey: string(line) + "\n"},
+			}
+			return s.client.Create(ctx, configMap)
+		}
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		lines := strings.Split(strings.TrimSuffix(configMap.Data[auditConfigMapKey], "\n"), "\n")
+		if lines[0] == "" {
+			lines = nil
+		}
+		lines = append(lines, string(line))
+		if len(lines) > s.maxEntries {
+			lines = lines[len(lines)-s.maxEntries:]
+		}
+		configMap.Data[auditConfigMapKey] = strings.Join(lines, "\n") + "\n"
+		return s.client.Update(ctx, configMap)
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const clusterBindRulesNotSupported = "Binding rules are not supported by ClusterConsulACL, use ConsulACL in the namespace of service account"
+
+// ClusterConsulACLReconciler reconciles a ClusterConsulACL object. It manages global policies and roles
+// which names are not prefixed with the name and the namespace of custom resource.
+type ClusterConsulACLReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=clusterconsulacls,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=clusterconsulacls/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=clusterconsulacls/finalizers,verbs=update
+
+func (r *ClusterConsulACLReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("Reconciling ClusterConsulACL")
+
+	instance := &consulacl.ClusterConsulACL{}
+	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	crUpdater := util.NewCustomResourceUpdater(r.Client, instance)
+	if instance.DeletionTimestamp.IsZero() {
+		if !util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			err = crUpdater.UpdateWithRetry(func(cr *consulacl.ClusterConsulACL) {
+				controllerutil.AddFinalizer(cr, consulAclFinalizer)
+			})
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	} else {
+		if util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			return r.deleteACL(instance, crUpdater)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	aclSystem := CheckACLSystem()
+	if !aclSystem.Ready {
+		reqLogger.Info(fmt.Sprintf("Consul ACL system is not ready: %s", aclSystem.Message))
+		err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ClusterConsulACL) {
+			meta.SetStatusCondition(&cr.Status.Conditions, newACLSystemCondition(aclSystem, cr.Generation))
+		})
+		if err != nil {
+			log.Error(err, "Error occurred during custom resource status update")
+		}
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+
+	aclConfig, err := getClusterAclConfig(instance)
+	if err != nil {
+		log.Error(err, "Can not parse ACL configuration")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	source := newAuditSource(instance)
+	policiesStatus, processedPolicies, err := processPolicies(aclConfig.Policies, globalEntityNamer, source)
+	if err == nil {
+		var rolesStatus *StatusHolder
+		rolesStatus, err = processRoles(aclConfig.Roles, processedPolicies, globalEntityNamer, source)
+		if err == nil {
+			err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ClusterConsulACL) {
+				cr.Status.PoliciesStatus = policiesStatus.GetStatus()
+				cr.Status.RolesStatus = rolesStatus.GetStatus()
+				cr.Status.BindRulesStatus = ""
+				if len(aclConfig.BindRules) > 0 {
+					cr.Status.BindRulesStatus = clusterBindRulesNotSupported
+				}
+				meta.SetStatusCondition(&cr.Status.Conditions, newACLSystemCondition(aclSystem, cr.Generation))
+			})
+			if err != nil {
+				log.Error(err, "Error occurred during custom resource status update")
+				return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+			}
+			reqLogger.Info("Reconcile cycle succeeded")
+			return reconcile.Result{}, nil
+		}
+	}
+	if _, ok := err.(net.Error); ok {
+		log.Error(err, "Error during connection to Consul")
+	}
+	return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterConsulACLReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consulacl.ClusterConsulACL{}, builder.WithPredicates(util.StatusPredicate())).
+		Complete(r)
+}
+
+func (r *ClusterConsulACLReconciler) deleteACL(instance *consulacl.ClusterConsulACL, crUpdater util.CustomResourceUpdater[*consulacl.ClusterConsulACL]) (ctrl.Result, error) {
+	aclConfig, err := getClusterAclConfig(instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	source := newAuditSource(instance)
+	if err = deleteRoles(aclConfig, globalEntityNamer, source); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = deletePolicies(aclConfig, globalEntityNamer, source); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info(fmt.Sprintf("All ACL entities for ClusterConsulACL resource with name - [%s] are deleted", instance.Name))
+
+	err = crUpdater.UpdateWithRetry(func(cr *consulacl.ClusterConsulACL) {
+		controllerutil.RemoveFinalizer(cr, consulAclFinalizer)
+	})
+	return ctrl.Result{}, err
+}
+
+func getClusterAclConfig(cr *consulacl.ClusterConsulACL) (*ACLConfig, error) {
+	if cr.Spec.ACL == nil {
+		return &ACLConfig{}, nil
+	}
+	return parseAclConfig(cr.Spec.ACL.Json)
+}
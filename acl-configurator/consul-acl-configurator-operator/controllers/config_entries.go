@@ -0,0 +1,99 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+)
+
+const (
+	conditionSynced   = "Synced"
+	reasonApplied     = "Applied"
+	reasonConsulError = "ConsulError"
+
+	// maxCASAttempts limits retries of check-and-set writes of config entries changed concurrently
+	maxCASAttempts = 5
+	// kubernetesNamespaceMeta is the meta key which consul-k8s sets to the namespace of registered services
+	kubernetesNamespaceMeta = "k8s-namespace"
+)
+
+// readConfigEntry returns nil if the config entry does not exist
+func readConfigEntry(kind string, name string) (consulApi.ConfigEntry, error) {
+	entry, _, err := consulClient.ConfigEntries().Get(kind, name, &consulApi.QueryOptions{})
+	var statusError consulApi.StatusError
+	if errors.As(err, &statusError) && statusError.Code == http.StatusNotFound {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// newSyncedCondition reports whether Consul entities of custom resource are written to Consul
+func newSyncedCondition(synced bool, reason string, message string, generation int64) metav1.Condition {
+	status := metav1.ConditionTrue
+	if !synced {
+		status = metav1.ConditionFalse
+	}
+	return metav1.Condition{
+		Type:               conditionSynced,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// findNotOwnedService returns the reason if the service is not registered in Consul catalog by the namespace.
+// The role describes the service in the reason, for example "source service".
+func findNotOwnedService(namespace string, name string, role string) (string, error) {
+	owners, err := findServiceOwners(name)
+	if err != nil {
+		return "", err
+	}
+	if len(owners) == 0 {
+		return fmt.Sprintf("%s [%s] is not registered in Consul", role, name), nil
+	}
+	return findOtherServiceOwner(namespace, name, role, owners), nil
+}
+
+// findServiceOwners returns namespaces of all instances of the service in Consul catalog,
+// the namespace is empty for instances which are not registered from Kubernetes
+func findServiceOwners(name string) ([]string, error) {
+	services, _, err := consulClient.Catalog().Service(name, "", &consulApi.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var owners []string
+	for _, service := range services {
+		owners = append(owners, service.ServiceMeta[kubernetesNamespaceMeta])
+	}
+	return owners, nil
+}
+
+// findOtherServiceOwner returns the reason if an instance of the service is registered not by the namespace
+func findOtherServiceOwner(namespace string, name string, role string, owners []string) string {
+	for _, owner := range owners {
+		if owner == "" {
+			return fmt.Sprintf("%s [%s] is not registered from Kubernetes", role, name)
+		}
+		if owner != namespace {
+			return fmt.Sprintf("%s [%s] is registered by namespace [%s]", role, name, owner)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"crypto/tls"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	tlsClientCertPath = "/consul/tls/client/tls.crt"
+	tlsClientKeyPath  = "/consul/tls/client/tls.key"
+
+	tlsReloadCheckPeriod = 10 * time.Second
+)
+
+var tlsMinVersions = map[string]uint16{
+	"TLSv1_0": tls.VersionTLS10,
+	"TLSv1_1": tls.VersionTLS11,
+	"TLSv1_2": tls.VersionTLS12,
+	"TLSv1_3": tls.VersionTLS13,
+}
+
+// getConsulTLSConfig returns TLS settings of Consul client from environment variables.
+// Certificates mounted to the default paths are used when the corresponding variables are not set.
+func getConsulTLSConfig() consulApi.TLSConfig {
+	return consulApi.TLSConfig{
+		Address:            os.Getenv("CONSUL_TLS_SERVER_NAME"),
+		CAFile:             getTLSFileEnv("CONSUL_TLS_CA_FILE", tlsCaCertPath),
+		CertFile:           getTLSFileEnv("CONSUL_TLS_CERT_FILE", tlsClientCertPath),
+		KeyFile:            getTLSFileEnv("CONSUL_TLS_KEY_FILE", tlsClientKeyPath),
+		InsecureSkipVerify: os.Getenv("CONSUL_TLS_INSECURE_SKIP_VERIFY") == "true",
+	}
+}
+
+func getTLSFileEnv(key string, defaultPath string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if _, err := os.Stat(defaultPath); err == nil {
+		return defaultPath
+	}
+	return ""
+}
+
+func getTLSMinVersion() (uint16, error) {
+	value := os.Getenv("CONSUL_TLS_MIN_VERSION")
+	if value == "" {
+		return tls.VersionTLS12, nil
+	}
+	version, ok := tlsMinVersions[value]
+	if !ok {
+		return 0, fmt.Errorf("CONSUL_TLS_MIN_VERSION must be one of TLSv1_0, TLSv1_1, TLSv1_2, TLSv1_3, but %s is specified", value)
+	}
+	return version, nil
+}
+
+// reloadingTransport sends requests to Consul via HTTP transport which is recreated when TLS certificates
+// are changed on disk, so rotated certificates are applied without restart of the operator
+type reloadingTransport struct {
+	tlsConfig  consulApi.TLSConfig
+	minVersion uint16
+
+	mutex       sync.Mutex
+	transport   *http.Transport
+	modTimes    map[string]time.Time
+	lastCheckAt time.Time
+}
+
+func newReloadingTransport(tlsConfig consulApi.TLSConfig) (*reloadingTransport, error) {
+	minVersion, err := getTLSMinVersion()
+	if err != nil {
+		return nil, err
+	}
+	t := &reloadingTransport{tlsConfig: tlsConfig, minVersion: minVersion}
+	t.modTimes = t.getModTimes()
+	if t.transport, err = t.newTransport(); err != nil {
+		return nil, err
+	}
+	t.lastCheckAt = time.Now()
+	return t, nil
+}
+
+func (t *reloadingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	return t.getTransport().RoundTrip(request)
+}
+
+func (t *reloadingTransport) getTransport() *http.Transport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if time.Since(t.lastCheckAt) < tlsReloadCheckPeriod {
+		return t.transport
+	}
+	t.lastCheckAt = time.Now()
+	modTimes := t.getModTimes()
+	if isModTimesEqual(t.modTimes, modTimes) {
+		return t.transport
+	}
+	transport, err := t.newTransport()
+	if err != nil {
+		// certificate and key may be updated not at the same time, so the check is repeated later
+		log.Error(err, "Can not reload Consul TLS certificates, previous ones are used")
+		return t.transport
+	}
+	log.Info("Consul TLS certificates are reloaded")
+	t.transport.CloseIdleConnections()
+	t.transport = transport
+	t.modTimes = modTimes
+	return t.transport
+}
+
+func (t *reloadingTransport) newTransport() (*http.Transport, error) {
+	transport := consulApi.DefaultConfig().Transport
+	tlsClientConfig, err := consulApi.SetupTLSConfig(&t.tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	tlsClientConfig.MinVersion = t.minVersion
+	transport.TLSClientConfig = tlsClientConfig
+	return transport, nil
+}
+
+func (t *reloadingTransport) getModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, path := range []string{t.tlsConfig.CAFile, t.tlsConfig.CertFile, t.tlsConfig.KeyFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func isModTimesEqual(first map[string]time.Time, second map[string]time.Time) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for path, modTime := range first {
+		if !modTime.Equal(second[path]) {
+			return false
+		}
+	}
+	return true
+}
@@ -21,12 +21,13 @@ import (
 	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
 	consulApi "github.com/hashicorp/consul/api"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"net"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-	"sigs.k8s.io/controller-runtime/pkg/event"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"strconv"
 	"strings"
@@ -37,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
 	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
 )
 
@@ -52,13 +54,17 @@ var ConsulClientScheme = os.Getenv("CONSUL_SCHEME")
 var bootstrapToken = os.Getenv("CONSUL_ACL_BOOTSTRAP_TOKEN")
 var authMethod = os.Getenv("CONSUL_AUTH_METHOD_NAME")
 var periodTime, _ = strconv.Atoi(os.Getenv("RECONCILE_PERIOD_SECONDS"))
-var aclClient = makeAclClient()
+var consulClient *consulApi.Client
+var aclClient *consulApi.ACL
 
 // ConsulACLReconciler reconciles a ConsulACL object
 type ConsulACLReconciler struct {
-	Client           client.Client
-	Scheme           *runtime.Scheme
-	ResourceVersions map[string]string
+	Client client.Client
+	// APIReader reads objects which are not cached by the Manager
+	APIReader               client.Reader
+	Scheme                  *runtime.Scheme
+	ResourceVersions        map[string]string
+	MaxConcurrentReconciles int
 }
 
 //+kubebuilder:rbac:groups=netcracker.com,resources=consulacls,verbs=get;list;watch;create;update;patch;delete
@@ -70,7 +76,7 @@ func (r *ConsulACLReconciler) Reconcile(ctx context.Context, request ctrl.Reques
 	reqLogger.Info("Reconciling ConsulACL")
 
 	// Fetch the ConsulACL instance
-	instance := &consulacl.ConsulACL{}
+	instance := &consulaclv1.ConsulACL{}
 	err := r.Client.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -86,7 +92,7 @@ func (r *ConsulACLReconciler) Reconcile(ctx context.Context, request ctrl.Reques
 	crUpdater := util.NewCustomResourceUpdater(r.Client, instance)
 	if instance.DeletionTimestamp.IsZero() {
 		if !util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
-			err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulACL) {
+			err = crUpdater.UpdateWithRetry(func(cr *consulaclv1.ConsulACL) {
 				controllerutil.AddFinalizer(cr, consulAclFinalizer)
 			})
 			if err != nil {
@@ -95,12 +101,74 @@ func (r *ConsulACLReconciler) Reconcile(ctx context.Context, request ctrl.Reques
 		}
 	} else {
 		if util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			if isPaused(instance) {
+				reqLogger.Info("Reconciliation is paused, Consul ACL entities are deleted after it is resumed")
+				return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+			}
 			return r.deleteACL(instance, crUpdater)
 		}
 		return reconcile.Result{}, nil
 	}
 
-	policiesStatus, rolesStatus, bindRulesStatus, err := r.applyACL(instance)
+	aclSystem := CheckACLSystem()
+	if !aclSystem.Ready {
+		reqLogger.Info(fmt.Sprintf("Consul ACL system is not ready: %s", aclSystem.Message))
+		err = crUpdater.UpdateStatusWithRetry(func(cr *consulaclv1.ConsulACL) {
+			meta.SetStatusCondition(&cr.Status.Conditions, newACLSystemCondition(aclSystem, cr.Generation))
+		})
+		if err != nil {
+			log.Error(err, "Error occurred during custom resource status update")
+		}
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+
+	aclConfig, rollbackCondition, err := r.getRollbackConfig(ctx, instance)
+	if err != nil {
+		log.Error(err, "Can not read ACL configuration history")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	rollback := aclConfig != nil
+	if !rollback && instance.GetAnnotations()[rollbackAnnotation] != "" {
+		reqLogger.Info(rollbackCondition.Message)
+		err = crUpdater.UpdateStatusWithRetry(func(cr *consulaclv1.ConsulACL) {
+			meta.SetStatusCondition(&cr.Status.Conditions, rollbackCondition)
+		})
+		if err != nil {
+			log.Error(err, "Error occurred during custom resource status update")
+		}
+		return reconcile.Result{}, nil
+	}
+	if !rollback {
+		aclConfig, err = getAclConfig(instance)
+		if err != nil {
+			log.Error(err, "Can not parse ACL configuration")
+			return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+		}
+	}
+
+	if isPaused(instance) {
+		return r.reportDrift(instance, crUpdater, aclConfig)
+	}
+
+	quotaUsage, err := getQuotaUsage(ctx, r.Client, instance, aclConfig, true)
+	if err != nil {
+		log.Error(err, "Can not check quotas of the namespace")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	if exceeded := findExceededQuotas(quotaUsage); len(exceeded) > 0 {
+		reqLogger.Info(fmt.Sprintf("Quotas of the namespace are exceeded: %s", strings.Join(exceeded, ", ")))
+		err = crUpdater.UpdateStatusWithRetry(func(cr *consulaclv1.ConsulACL) {
+			cr.Status.QuotaUsage = quotaUsage
+			meta.SetStatusCondition(&cr.Status.Conditions, newQuotaCondition(exceeded, cr.Generation))
+		})
+		if err != nil {
+			log.Error(err, "Error occurred during custom resource status update")
+		}
+		// quotas can be released by other custom resources, so the check is repeated
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+
+	result, err := r.applyACL(ctx, instance, aclConfig)
 	if err != nil {
 		if _, ok := err.(net.Error); ok {
 			log.Error(err, "Error during connection to Consul")
@@ -110,62 +178,88 @@ func (r *ConsulACLReconciler) Reconcile(ctx context.Context, request ctrl.Reques
 		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
 	}
 
-	err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ConsulACL) {
-		cr.Status.PoliciesStatus = policiesStatus
-		cr.Status.RolesStatus = rolesStatus
-		cr.Status.BindRulesStatus = bindRulesStatus
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulaclv1.ConsulACL) {
+		cr.Status.PoliciesStatus = result.policiesStatus
+		cr.Status.RolesStatus = result.rolesStatus
+		cr.Status.BindRulesStatus = result.bindRulesStatus
+		cr.Status.QuotaUsage = quotaUsage
+		meta.SetStatusCondition(&cr.Status.Conditions, newACLSystemCondition(aclSystem, cr.Generation))
+		meta.SetStatusCondition(&cr.Status.Conditions, rollbackCondition)
+		meta.SetStatusCondition(&cr.Status.Conditions, newPausedCondition(false, cr.Generation))
+		meta.SetStatusCondition(&cr.Status.Conditions, newQuotaCondition(nil, cr.Generation))
+		meta.RemoveStatusCondition(&cr.Status.Conditions, conditionDriftDetected)
+		for _, condition := range result.conditions {
+			meta.SetStatusCondition(&cr.Status.Conditions, condition)
+		}
 	})
 	if err != nil {
 		log.Error(err, "Error occurred during custom resource status update")
 		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
 	}
 
+	if !rollback {
+		if err = r.saveHistory(ctx, instance, result.appliedConfig); err != nil {
+			log.Error(err, "Can not save ACL configuration to history")
+		}
+	}
+
 	reqLogger.Info("Reconcile cycle succeeded")
 	return reconcile.Result{}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ConsulACLReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	statusPredicate := predicate.Funcs{
-		UpdateFunc: func(e event.UpdateEvent) bool {
-			// Ignore updates to CR status in which case metadata.Generation does not change
-			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
-		},
-		DeleteFunc: func(e event.DeleteEvent) bool {
-			// Evaluates to false if the object has been confirmed deleted.
-			return !e.DeleteStateUnknown
-		},
-	}
-
+	// react on approval of privileged rules, rollback and pause
+	statusPredicate := util.StatusPredicate(approvalAnnotation, rollbackAnnotation, pausedAnnotation)
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&consulacl.ConsulACL{}, builder.WithPredicates(statusPredicate)).
+		For(&consulaclv1.ConsulACL{}, builder.WithPredicates(statusPredicate)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
-func (r *ConsulACLReconciler) deleteACL(instance *consulacl.ConsulACL, crUpdater util.CustomResourceUpdater) (ctrl.Result, error) {
+// reportDrift updates the status of paused custom resource with differences between ACL configuration and Consul
+// and requeues it to keep the status up to date
+func (r *ConsulACLReconciler) reportDrift(instance *consulaclv1.ConsulACL, crUpdater util.CustomResourceUpdater[*consulaclv1.ConsulACL], aclConfig *ACLConfig) (ctrl.Result, error) {
+	drifts, err := detectDrift(aclConfig, instance.Name, instance.Namespace)
+	if err != nil {
+		log.Error(err, "Can not detect drift of Consul ACL entities")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulaclv1.ConsulACL) {
+		meta.SetStatusCondition(&cr.Status.Conditions, newPausedCondition(true, cr.Generation))
+		meta.SetStatusCondition(&cr.Status.Conditions, newDriftCondition(drifts, cr.Generation))
+	})
+	if err != nil {
+		log.Error(err, "Error occurred during custom resource status update")
+	}
+	return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+}
+
+func (r *ConsulACLReconciler) deleteACL(instance *consulaclv1.ConsulACL, crUpdater util.CustomResourceUpdater[*consulaclv1.ConsulACL]) (ctrl.Result, error) {
 	aclConfig, err := getAclConfig(instance)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	err = r.deleteAclEntities(aclConfig, instance.Name, instance.Namespace)
+	err = r.deleteAclEntities(aclConfig, instance.Name, instance.Namespace, newAuditSource(instance))
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulACL) {
+	err = crUpdater.UpdateWithRetry(func(cr *consulaclv1.ConsulACL) {
 		controllerutil.RemoveFinalizer(cr, consulAclFinalizer)
 	})
 	return ctrl.Result{}, err
 }
 
-func (r *ConsulACLReconciler) deleteAclEntities(aclConfig *ACLConfig, name string, namespace string) error {
-	if err := deleteBindingRules(aclConfig, name, namespace); err != nil {
+func (r *ConsulACLReconciler) deleteAclEntities(aclConfig *ACLConfig, name string, namespace string, source *auditSource) error {
+	if err := deleteBindingRules(aclConfig, name, namespace, source); err != nil {
 		return err
 	}
-	if err := deleteRoles(aclConfig, name, namespace); err != nil {
+	namer := namespacedEntityNamer(name, namespace)
+	if err := deleteRoles(aclConfig, namer, source); err != nil {
 		return err
 	}
-	if err := deletePolicies(aclConfig, name, namespace); err != nil {
+	if err := deletePolicies(aclConfig, namer, source); err != nil {
 		return err
 	}
 	log.Info(fmt.Sprintf("All ACL entities for ConsulACL resource with name - [%s] from namespace - [%s] are deleted",
@@ -173,95 +267,340 @@ func (r *ConsulACLReconciler) deleteAclEntities(aclConfig *ACLConfig, name strin
 	return nil
 }
 
-func deleteBindingRules(aclConfig *ACLConfig, name string, namespace string) error {
+func deleteBindingRules(aclConfig *ACLConfig, name string, namespace string, source *auditSource) error {
 	existedBindingRules, _, err := aclClient.BindingRuleList(authMethod, &consulApi.QueryOptions{})
 	if err != nil {
 		return err
 	}
 	for _, br := range aclConfig.BindRules {
-		for _, ebr := range existedBindingRules {
-			if convertEntityName(br.BindName, name, namespace) == ebr.BindName {
-				_, err = aclClient.BindingRuleDelete(ebr.ID, &consulApi.WriteOptions{})
-				if err != nil {
-					log.Error(err, fmt.Sprintf("Error occurred during binding rule deleting operation, binding rule id is [%s]", ebr.ID))
-					return err
-				}
+		bindRule := convertBindRuleAdapterToBindRule(br, name, namespace)
+		if err = deleteBindingRulesByBindName(bindRule, existedBindingRules, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBindingRulesByBindName deletes binding rules with the same bind name. Binding rules to templated policies
+// are shared between custom resources, so they are also matched by selector.
+func deleteBindingRulesByBindName(bindRule consulApi.ACLBindingRule, existedBindingRules []*consulApi.ACLBindingRule, source *auditSource) error {
+	bindName := bindRule.BindName
+	defer entityLocks.Lock(bindingRuleLockPrefix + bindName)()
+	for _, ebr := range existedBindingRules {
+		if bindName == ebr.BindName &&
+			(bindRule.BindType != consulApi.BindingRuleBindTypeTemplatedPolicy || bindRule.Selector == ebr.Selector) {
+			_, err := aclClient.BindingRuleDelete(ebr.ID, &consulApi.WriteOptions{})
+			if err != nil {
+				log.Error(err, fmt.Sprintf("Error occurred during binding rule deleting operation, binding rule id is [%s]", ebr.ID))
+				return err
 			}
+			recordAudit(source, "delete", auditEntityBindingRule, bindName, bindingRuleAuditLines(ebr), nil)
 		}
 	}
 	return nil
 }
 
-func deleteRoles(aclConfig *ACLConfig, name string, namespace string) error {
+func deleteRoles(aclConfig *ACLConfig, namer entityNamer, source *auditSource) error {
 	roles := aclConfig.Roles
 	for _, role := range roles {
-		roleName := convertEntityName(role.Name, name, namespace)
-		deletedRole, err := readRole(roleName)
-		if err != nil {
-			log.Error(err, fmt.Sprintf("Error occurred during role reading operation, role name is [%s]", roleName))
-			return err
-		} else if deletedRole == nil {
-			// skip deleting non-existent role
-			continue
-		}
-		_, err = aclClient.RoleDelete(deletedRole.ID, &consulApi.WriteOptions{})
-		if err != nil {
-			log.Error(err, fmt.Sprintf("Error occurred during role deleting operation, role id is [%s]", deletedRole.ID))
+		roleName := namer.name(role.Name)
+		if err := deleteRole(roleName, source); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func deletePolicies(aclConfig *ACLConfig, name string, namespace string) error {
+func deleteRole(roleName string, source *auditSource) error {
+	defer entityLocks.Lock(roleLockPrefix + roleName)()
+	deletedRole, err := readRole(roleName)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("Error occurred during role reading operation, role name is [%s]", roleName))
+		return err
+	} else if deletedRole == nil {
+		// skip deleting non-existent role
+		return nil
+	}
+	_, err = aclClient.RoleDelete(deletedRole.ID, &consulApi.WriteOptions{})
+	if err != nil {
+		log.Error(err, fmt.Sprintf("Error occurred during role deleting operation, role id is [%s]", deletedRole.ID))
+	} else {
+		recordAudit(source, "delete", auditEntityRole, roleName, roleAuditLines(deletedRole), nil)
+	}
+	return err
+}
+
+func deletePolicies(aclConfig *ACLConfig, namer entityNamer, source *auditSource) error {
 	policies := aclConfig.Policies
 	for _, policy := range policies {
-		policyName := convertEntityName(policy.Name, name, namespace)
-		deletedPolicy, err := readPolicy(policyName)
-		if err != nil {
-			log.Error(err, fmt.Sprintf("Error occurred during policy reading operation, policy name is [%s]", policyName))
-			return err
-		} else if deletedPolicy == nil {
-			// skip deleting non-existent policy
-			continue
-		}
-		_, err = aclClient.PolicyDelete(deletedPolicy.ID, &consulApi.WriteOptions{})
-		if err != nil {
-			log.Error(err, fmt.Sprintf("Error occurred during policy deleting operation, policy id is [%s]", deletedPolicy.ID))
+		policyName := namer.name(policy.Name)
+		if err := deletePolicy(policyName, source); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func convertEntityName(entityName string, name string, namespace string) string {
-	return fmt.Sprintf("%s_%s_%s", name, namespace, entityName)
+func deletePolicy(policyName string, source *auditSource) error {
+	defer entityLocks.Lock(policyLockPrefix + policyName)()
+	deletedPolicy, err := readPolicy(policyName)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("Error occurred during policy reading operation, policy name is [%s]", policyName))
+		return err
+	} else if deletedPolicy == nil {
+		// skip deleting non-existent policy
+		return nil
+	}
+	_, err = aclClient.PolicyDelete(deletedPolicy.ID, &consulApi.WriteOptions{})
+	if err != nil {
+		log.Error(err, fmt.Sprintf("Error occurred during policy deleting operation, policy id is [%s]", deletedPolicy.ID))
+	} else {
+		recordAudit(source, "delete", auditEntityPolicy, policyName, policyAuditLines(deletedPolicy), nil)
+	}
+	return err
 }
 
-func (r *ConsulACLReconciler) applyACL(cr *consulacl.ConsulACL) (string, string, string, error) {
+// aclApplyResult contains statuses of ACL entities and conditions which are observed during ACL configuration applying
+type aclApplyResult struct {
+	policiesStatus  string
+	rolesStatus     string
+	bindRulesStatus string
+	conditions      []metav1.Condition
+	// appliedConfig is ACL configuration without policies which are forbidden by guardrails or are not approved
+	appliedConfig *ACLConfig
+}
+
+// applyACL applies ACL configuration of custom resource. Guardrails and approval are checked for configuration
+// from the history as well, because the history is stored in the namespace of custom resource.
+func (r *ConsulACLReconciler) applyACL(ctx context.Context, cr *consulaclv1.ConsulACL, aclConfig *ACLConfig) (*aclApplyResult, error) {
 	customResourceName := cr.Name
 	customResourceNamespace := cr.Namespace
-	aclConfig, err := getAclConfig(cr)
+	namer := namespacedEntityNamer(customResourceName, customResourceNamespace)
+	source := newAuditSource(cr)
+	result := &aclApplyResult{}
+	// templated policies of roles and binding rules are checked as policies, so they can not grant more than policies
+	usages, renderErrors, err := renderTemplatedPolicies(aclConfig, customResourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+	checkedPolicies := excludePolicies(append(usages.policies(), aclConfig.Policies...), renderErrors)
+	violations, err := findGuardrailViolations(ctx, r.Client, customResourceNamespace, checkedPolicies)
+	if err != nil {
+		return nil, err
+	}
+	result.conditions = append(result.conditions, newGuardrailsCondition(violations, cr.Generation))
+	checkedPolicies = excludePolicies(checkedPolicies, violations)
+
+	pending, approvalCondition, err := checkApproval(cr, aclConfig, checkedPolicies)
+	if err != nil {
+		return nil, err
+	}
+	result.conditions = append(result.conditions, approvalCondition)
+
+	_, unrenderedRoles, unrenderedBindRules := usages.split(renderErrors)
+	violations, forbiddenRoles, forbiddenBindRules := usages.split(violations)
+	pendingPolicies, pendingRoles, pendingBindRules := usages.split(pending)
+	policies := excludePolicies(excludePolicies(aclConfig.Policies, violations), pendingPolicies)
+	invalidRoles, err := checkGlobalPolicyReferences(ctx, r.Client, customResourceNamespace, aclConfig.Roles)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
-	policiesStatus, processedPolicies, err := processPolicies(aclConfig.Policies, customResourceName, customResourceNamespace)
+	invalidRoles = mergeReasons(invalidRoles, unrenderedRoles, forbiddenRoles)
+	roles := excludeRoles(excludeRoles(aclConfig.Roles, invalidRoles), pendingRoles)
+	invalidBindRules := mergeBindRuleReasons(checkBindTypes(aclConfig.BindRules), unrenderedBindRules, forbiddenBindRules)
+	bindRules := excludeBindRules(excludeBindRules(aclConfig.BindRules, invalidBindRules), pendingBindRules)
+	result.appliedConfig = &ACLConfig{Policies: policies, Roles: roles, BindRules: bindRules}
+
+	policiesStatus, processedPolicies, err := processPolicies(policies, namer, source)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
+	}
+	for policyName, reason := range violations {
+		(*policiesStatus)[namer.name(policyName)] = fmt.Sprintf("error: %s", reason)
+	}
+	for policyName, reason := range pendingPolicies {
+		(*policiesStatus)[namer.name(policyName)] = reason
 	}
-	rolesStatus, err := processRoles(aclConfig.Roles, processedPolicies, customResourceName, customResourceNamespace)
+	rolesStatus, err := processRoles(roles, processedPolicies, namer, source)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
+	}
+	for roleName, reason := range invalidRoles {
+		(*rolesStatus)[namer.name(roleName)] = fmt.Sprintf("error: %s", reason)
+	}
+	for roleName, reason := range pendingRoles {
+		(*rolesStatus)[namer.name(roleName)] = reason
 	}
-	bindRulesStatus, err := processBindRules(aclConfig.BindRules, customResourceName, customResourceNamespace)
+	bindRulesStatus, err := processBindRules(bindRules, customResourceName, customResourceNamespace, source)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
+	}
+	for i, reason := range invalidBindRules {
+		(*bindRulesStatus)[aclConfig.BindRules[i].BindName] = fmt.Sprintf("error: %s", reason)
+	}
+	for i, reason := range pendingBindRules {
+		(*bindRulesStatus)[aclConfig.BindRules[i].BindName] = reason
 	}
-	return policiesStatus.GetStatus(), rolesStatus.GetStatus(), bindRulesStatus.GetStatus(), nil
+	result.policiesStatus = policiesStatus.GetStatus()
+	result.rolesStatus = rolesStatus.GetStatus()
+	result.bindRulesStatus = bindRulesStatus.GetStatus()
+	return result, nil
 }
 
-func getAclConfig(cr *consulacl.ConsulACL) (*ACLConfig, error) {
-	jsonField := cr.Spec.ACL.Json
+func newGuardrailsCondition(violations map[string]string, generation int64) metav1.Condition {
+	if len(violations) == 0 {
+		return metav1.Condition{
+			Type:               conditionGuardrailsSatisfied,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             reasonRulesAllowed,
+			Message:            "All policies are allowed by guardrails",
+		}
+	}
+	return metav1.Condition{
+		Type:               conditionGuardrailsSatisfied,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             reasonRulesForbidden,
+		Message:            formatGuardrailViolations(violations),
+	}
+}
+
+// excludePolicies returns policies which names are not present in excluded map
+func excludePolicies(policies []ACLPolicyAdapter, excluded map[string]string) []ACLPolicyAdapter {
+	if len(excluded) == 0 {
+		return policies
+	}
+	var result []ACLPolicyAdapter
+	for _, policy := range policies {
+		if _, ok := excluded[policy.Name]; !ok {
+			result = append(result, policy)
+		}
+	}
+	return result
+}
+
+// excludeRoles returns roles which names are not present in excluded map
+func excludeRoles(roles []ACLRoleAdapter, excluded map[string]string) []ACLRoleAdapter {
+	if len(excluded) == 0 {
+		return roles
+	}
+	var result []ACLRoleAdapter
+	for _, role := range roles {
+		if _, ok := excluded[role.Name]; !ok {
+			result = append(result, role)
+		}
+	}
+	return result
+}
+
+// excludeBindRules returns binding rules which indexes are not present in excluded map
+func excludeBindRules(bindRules []ACLBindingRuleAdapter, excluded map[int]string) []ACLBindingRuleAdapter {
+	if len(excluded) == 0 {
+		return bindRules
+	}
+	var result []ACLBindingRuleAdapter
+	for i, bindRule := range bindRules {
+		if _, ok := excluded[i]; !ok {
+			result = append(result, bindRule)
+		}
+	}
+	return result
+}
+
+// checkBindTypes returns the reason for each binding rule which bind type is not supported by its index
+func checkBindTypes(bindRules []ACLBindingRuleAdapter) map[int]string {
+	invalid := map[int]string{}
+	for i, bindRule := range bindRules {
+		if err := checkBindType(bindRule.BindType); err != nil {
+			invalid[i] = err.Error()
+		}
+	}
+	return invalid
+}
+
+// mergeReasons returns reasons of all maps, the first reason is kept for each name
+func mergeReasons(reasons ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for i := len(reasons) - 1; i >= 0; i-- {
+		for name, reason := range reasons[i] {
+			merged[name] = reason
+		}
+	}
+	return merged
+}
+
+func mergeBindRuleReasons(reasons ...map[int]string) map[int]string {
+	merged := map[int]string{}
+	for i := len(reasons) - 1; i >= 0; i-- {
+		for index, reason := range reasons[i] {
+			merged[index] = reason
+		}
+	}
+	return merged
+}
+
+// getAclConfig returns ACL configuration of custom resource. The JSON specification of v1alpha1 custom resource is kept
+// in the annotation by conversion, and it is used while it matches the structured specification, so fields which are absent
+// in v1 are still applied and the parsing error of invalid JSON is returned instead of empty configuration.
+func getAclConfig(cr *consulaclv1.ConsulACL) (*ACLConfig, error) {
+	if originalJSON, ok := consulacl.OriginalACLJSON(cr); ok {
+		return parseAclConfig(originalJSON)
+	}
+	return newACLConfig(cr.Spec), nil
+}
+
+// newACLConfig converts the structured specification of ConsulACL to ACL configuration
+func newACLConfig(spec consulaclv1.ConsulACLSpec) *ACLConfig {
+	aclConfig := &ACLConfig{}
+	for _, policy := range spec.Policies {
+		adapter := ACLPolicyAdapter{Frozen: policy.Frozen}
+		adapter.ID = policy.ID
+		adapter.Name = policy.Name
+		adapter.Description = policy.Description
+		adapter.Rules = policy.Rules
+		adapter.Datacenters = policy.Datacenters
+		aclConfig.Policies = append(aclConfig.Policies, adapter)
+	}
+	for _, role := range spec.Roles {
+		adapter := ACLRoleAdapter{
+			ID:                role.ID,
+			Name:              role.Name,
+			Description:       role.Description,
+			PolicyNames:       role.PolicyNames,
+			GlobalPolicyNames: role.GlobalPolicyNames,
+			Frozen:            role.Frozen,
+		}
+		for _, templatedPolicy := range role.TemplatedPolicies {
+			adapter.TemplatedPolicies = append(adapter.TemplatedPolicies, ACLTemplatedPolicyAdapter{
+				TemplateName:      templatedPolicy.TemplateName,
+				TemplateVariables: newTemplatedPolicyVariables(templatedPolicy.Variables),
+				Datacenters:       templatedPolicy.Datacenters,
+			})
+		}
+		aclConfig.Roles = append(aclConfig.Roles, adapter)
+	}
+	for _, bindRule := range spec.BindRules {
+		aclConfig.BindRules = append(aclConfig.BindRules, ACLBindingRuleAdapter{
+			ID:                 bindRule.ID,
+			Description:        bindRule.Description,
+			ServiceAccountName: bindRule.ServiceAccountName,
+			BindType:           bindRule.BindType,
+			BindName:           bindRule.BindName,
+			BindVars:           newTemplatedPolicyVariables(bindRule.BindVars),
+		})
+	}
+	return aclConfig
+}
+
+func newTemplatedPolicyVariables(variables *consulaclv1.TemplatedPolicyVariables) *consulApi.ACLTemplatedPolicyVariables {
+	if variables == nil {
+		return nil
+	}
+	return &consulApi.ACLTemplatedPolicyVariables{Name: variables.Name}
+}
+
+func parseAclConfig(jsonField string) (*ACLConfig, error) {
 	aclConfig := ACLConfig{}
 	jsonBytes := []byte(jsonField)
 	err := json.Unmarshal(jsonBytes, &aclConfig)
@@ -271,7 +610,7 @@ func getAclConfig(cr *consulacl.ConsulACL) (*ACLConfig, error) {
 	return &aclConfig, nil
 }
 
-func processPolicies(policies []consulApi.ACLPolicy, customResourceName string, customResourceNamespace string) (*StatusHolder, map[string]string, error) {
+func processPolicies(policies []ACLPolicyAdapter, namer entityNamer, source *auditSource) (*StatusHolder, map[string]string, error) {
 	statusMap := StatusHolder{}
 	processedPolicies := map[string]string{}
 	var err error
@@ -280,27 +619,25 @@ func processPolicies(policies []consulApi.ACLPolicy, customResourceName string,
 			statusMap["innerErrorHandlingItem"] = "Some policies have not got a name"
 			continue
 		} else {
-			policyDemand.Name = fmt.Sprintf("%s_%s_%s", customResourceName, customResourceNamespace, policyDemand.Name)
+			policyDemand.Description = namer.description(policyDemand.Description, policyDemand.Name)
+			policyDemand.Name = namer.name(policyDemand.Name)
 		}
-		var resPolicy *consulApi.ACLPolicy
-		var action string
-
-		if policyDemand.ID == "" {
-			resPolicy, err = readPolicy(policyDemand.Name)
-			if err != nil {
-				log.Info(fmt.Sprintf("Error occurred during reading a policy by name - %s, %s", policyDemand.Name, err.Error()))
-			} else if resPolicy != nil {
-				policyDemand.ID = resPolicy.ID
+		if policyDemand.Frozen {
+			frozenPolicy, readErr := readPolicy(policyDemand.Name)
+			if readErr != nil {
+				err = readErr
+				statusMap[policyDemand.Name] = fmt.Sprintf("error: %s", err)
+				continue
+			}
+			if frozenPolicy != nil {
+				processedPolicies[policyDemand.Name] = frozenPolicy.ID
+				statusMap[policyDemand.Name] = "frozen"
+				continue
 			}
 		}
-
-		if policyDemand.ID == "" {
-			action = "create"
-			resPolicy, _, err = aclClient.PolicyCreate(&policyDemand, &consulApi.WriteOptions{})
-		} else {
-			action = "update"
-			resPolicy, _, err = aclClient.PolicyUpdate(&policyDemand, &consulApi.WriteOptions{})
-		}
+		var resPolicy *consulApi.ACLPolicy
+		var action string
+		resPolicy, action, err = applyPolicy(policyDemand.ACLPolicy, source)
 
 		if err != nil {
 			log.Error(err, fmt.Sprintf("Can not %s a policy", action))
@@ -317,7 +654,36 @@ func processPolicies(policies []consulApi.ACLPolicy, customResourceName string,
 	return &statusMap, processedPolicies, err
 }
 
-func processRoles(roles []ACLRoleAdapter, policies map[string]string, customResourceName string, customResourceNamespace string) (*StatusHolder, error) {
+func applyPolicy(policyDemand consulApi.ACLPolicy, source *auditSource) (*consulApi.ACLPolicy, string, error) {
+	defer entityLocks.Lock(policyLockPrefix + policyDemand.Name)()
+	var existedPolicy *consulApi.ACLPolicy
+	if policyDemand.ID == "" {
+		resPolicy, err := readPolicy(policyDemand.Name)
+		if err != nil {
+			log.Info(fmt.Sprintf("Error occurred during reading a policy by name - %s, %s", policyDemand.Name, err.Error()))
+		} else if resPolicy != nil {
+			policyDemand.ID = resPolicy.ID
+			existedPolicy = resPolicy
+		}
+	} else if isAuditEnabled(source) {
+		existedPolicy, _, _ = aclClient.PolicyRead(policyDemand.ID, &consulApi.QueryOptions{})
+	}
+
+	if policyDemand.ID == "" {
+		resPolicy, _, err := aclClient.PolicyCreate(&policyDemand, &consulApi.WriteOptions{})
+		if err == nil {
+			recordAudit(source, "create", auditEntityPolicy, policyDemand.Name, nil, policyAuditLines(&policyDemand))
+		}
+		return resPolicy, "create", err
+	}
+	resPolicy, _, err := aclClient.PolicyUpdate(&policyDemand, &consulApi.WriteOptions{})
+	if err == nil {
+		recordAudit(source, "update", auditEntityPolicy, policyDemand.Name, policyAuditLines(existedPolicy), policyAuditLines(&policyDemand))
+	}
+	return resPolicy, "update", err
+}
+
+func processRoles(roles []ACLRoleAdapter, policies map[string]string, namer entityNamer, source *auditSource) (*StatusHolder, error) {
 	statusMap := StatusHolder{}
 	var err error
 	for _, roleAdapter := range roles {
@@ -325,26 +691,25 @@ func processRoles(roles []ACLRoleAdapter, policies map[string]string, customReso
 			statusMap["innerErrorHandlingItem"] = "Some roles have not got a name"
 			continue
 		}
-		var resRole *consulApi.ACLRole
 		var action string
-		role := convertRoleAdapterToRole(roleAdapter, policies, customResourceName, customResourceNamespace)
-
-		if role.ID == "" {
-			resRole, err = readRole(role.Name)
-			if err != nil {
-				log.Info(fmt.Sprintf("Error occurred during reading a role by name - %s, %s", role.Name, err.Error()))
-			} else if resRole != nil {
-				role.ID = resRole.ID
-			}
+		role := convertRoleAdapterToRole(roleAdapter, policies, namer)
+		if templateErr := checkTemplatedPolicies(getRoleTemplateNames(role)...); templateErr != nil {
+			statusMap[role.Name] = fmt.Sprintf("error: %s", templateErr)
+			continue
 		}
-
-		if role.ID == "" {
-			action = "create"
-			_, _, err = aclClient.RoleCreate(&role, &consulApi.WriteOptions{})
-		} else {
-			action = "update"
-			_, _, err = aclClient.RoleUpdate(&role, &consulApi.WriteOptions{})
+		if roleAdapter.Frozen {
+			frozenRole, readErr := readRole(role.Name)
+			if readErr != nil {
+				err = readErr
+				statusMap[role.Name] = fmt.Sprintf("error: %s", err)
+				continue
+			}
+			if frozenRole != nil {
+				statusMap[role.Name] = "frozen"
+				continue
+			}
 		}
+		action, err = applyRole(role, source)
 
 		if err != nil {
 			log.Error(err, fmt.Sprintf("can not %s a role", action))
@@ -360,29 +725,68 @@ func processRoles(roles []ACLRoleAdapter, policies map[string]string, customReso
 	return &statusMap, err
 }
 
-func convertRoleAdapterToRole(roleAdapter ACLRoleAdapter, policies map[string]string, customResourceName string, customResourceNamespace string) consulApi.ACLRole {
+func applyRole(role consulApi.ACLRole, source *auditSource) (string, error) {
+	defer entityLocks.Lock(roleLockPrefix + role.Name)()
+	var existedRole *consulApi.ACLRole
+	if role.ID == "" {
+		resRole, err := readRole(role.Name)
+		if err != nil {
+			log.Info(fmt.Sprintf("Error occurred during reading a role by name - %s, %s", role.Name, err.Error()))
+		} else if resRole != nil {
+			role.ID = resRole.ID
+			existedRole = resRole
+		}
+	} else if isAuditEnabled(source) {
+		existedRole, _, _ = aclClient.RoleRead(role.ID, &consulApi.QueryOptions{})
+	}
+
+	if role.ID == "" {
+		_, _, err := aclClient.RoleCreate(&role, &consulApi.WriteOptions{})
+		if err == nil {
+			recordAudit(source, "create", auditEntityRole, role.Name, nil, roleAuditLines(&role))
+		}
+		return "create", err
+	}
+	_, _, err := aclClient.RoleUpdate(&role, &consulApi.WriteOptions{})
+	if err == nil {
+		recordAudit(source, "update", auditEntityRole, role.Name, roleAuditLines(existedRole), roleAuditLines(&role))
+	}
+	return "update", err
+}
+
+func convertRoleAdapterToRole(roleAdapter ACLRoleAdapter, policies map[string]string, namer entityNamer) consulApi.ACLRole {
 	role := consulApi.ACLRole{}
 	role.ID = roleAdapter.ID
-	role.Name = fmt.Sprintf("%s_%s_%s", customResourceName, customResourceNamespace, roleAdapter.Name)
-	role.Description = roleAdapter.Description
-	role.Policies = getPolicyLinks(roleAdapter, policies, customResourceName, customResourceNamespace)
+	role.Name = namer.name(roleAdapter.Name)
+	role.Description = namer.description(roleAdapter.Description, roleAdapter.Name)
+	role.Policies = getPolicyLinks(roleAdapter, policies, namer)
+	for _, templatedPolicy := range roleAdapter.TemplatedPolicies {
+		role.TemplatedPolicies = append(role.TemplatedPolicies, &consulApi.ACLTemplatedPolicy{
+			TemplateName:      templatedPolicy.TemplateName,
+			TemplateVariables: templatedPolicy.TemplateVariables,
+			Datacenters:       templatedPolicy.Datacenters,
+		})
+	}
 	return role
 }
 
-func getPolicyLinks(roleAdapter ACLRoleAdapter, policies map[string]string, customResourceName string, customResourceNamespace string) []*consulApi.ACLRolePolicyLink {
+func getPolicyLinks(roleAdapter ACLRoleAdapter, policies map[string]string, namer entityNamer) []*consulApi.ACLRolePolicyLink {
 	var resLinks []*consulApi.ACLRolePolicyLink
 	for _, policyName := range roleAdapter.PolicyNames {
-		if policyID, ok := policies[fmt.Sprintf("%s_%s_%s", customResourceName, customResourceNamespace, policyName)]; ok {
+		if policyID, ok := policies[namer.name(policyName)]; ok {
 			policyLink := consulApi.ACLRolePolicyLink{}
-			policyLink.Name = fmt.Sprintf("%s_%s_%s", customResourceName, customResourceNamespace, policyName)
+			policyLink.Name = namer.name(policyName)
 			policyLink.ID = policyID
 			resLinks = append(resLinks, &policyLink)
 		}
 	}
+	for _, policyName := range roleAdapter.GlobalPolicyNames {
+		resLinks = append(resLinks, &consulApi.ACLRolePolicyLink{Name: globalEntityNamer.name(policyName)})
+	}
 	return resLinks
 }
 
-func processBindRules(bindRules []ACLBindingRuleAdapter, customResourceName string, customResourceNamespace string) (*StatusHolder, error) {
+func processBindRules(bindRules []ACLBindingRuleAdapter, customResourceName string, customResourceNamespace string, source *auditSource) (*StatusHolder, error) {
 	statusMap := StatusHolder{}
 	var err error
 	//TODO: bind rule created every time with new id and maybe similar other fields
@@ -392,14 +796,14 @@ func processBindRules(bindRules []ACLBindingRuleAdapter, customResourceName stri
 			continue
 		}
 		bindRuleDemand := convertBindRuleAdapterToBindRule(bindRuleAdapter, customResourceName, customResourceNamespace)
-		var action string
-		if bindRuleDemand.ID == "" {
-			_, _, err = aclClient.BindingRuleCreate(&bindRuleDemand, &consulApi.WriteOptions{})
-			action = "create"
-		} else {
-			_, _, err = aclClient.BindingRuleUpdate(&bindRuleDemand, &consulApi.WriteOptions{})
-			action = "update"
+		if bindRuleDemand.BindType == consulApi.BindingRuleBindTypeTemplatedPolicy {
+			if templateErr := checkTemplatedPolicies(bindRuleDemand.BindName); templateErr != nil {
+				statusMap[bindRuleDemand.BindName] = fmt.Sprintf("error: %s", templateErr)
+				continue
+			}
 		}
+		var action string
+		action, err = applyBindRule(bindRuleDemand, source)
 		if err != nil {
 			log.Error(err, fmt.Sprintf("can not %s a bind rule", action))
 			statusMap[bindRuleDemand.BindName] = fmt.Sprintf("error: %s", err)
@@ -415,13 +819,41 @@ func processBindRules(bindRules []ACLBindingRuleAdapter, customResourceName stri
 	return &statusMap, err
 }
 
+func applyBindRule(bindRuleDemand consulApi.ACLBindingRule, source *auditSource) (string, error) {
+	defer entityLocks.Lock(bindingRuleLockPrefix + bindRuleDemand.BindName)()
+	if bindRuleDemand.ID == "" {
+		_, _, err := aclClient.BindingRuleCreate(&bindRuleDemand, &consulApi.WriteOptions{})
+		if err == nil {
+			recordAudit(source, "create", auditEntityBindingRule, bindRuleDemand.BindName, nil, bindingRuleAuditLines(&bindRuleDemand))
+		}
+		return "create", err
+	}
+	var existedRule *consulApi.ACLBindingRule
+	if isAuditEnabled(source) {
+		existedRule, _, _ = aclClient.BindingRuleRead(bindRuleDemand.ID, &consulApi.QueryOptions{})
+	}
+	_, _, err := aclClient.BindingRuleUpdate(&bindRuleDemand, &consulApi.WriteOptions{})
+	if err == nil {
+		recordAudit(source, "update", auditEntityBindingRule, bindRuleDemand.BindName, bindingRuleAuditLines(existedRule), bindingRuleAuditLines(&bindRuleDemand))
+	}
+	return "update", err
+}
+
 func convertBindRuleAdapterToBindRule(bindRuleAdapter ACLBindingRuleAdapter, customResourceName string, customResourceNamespace string) consulApi.ACLBindingRule {
+	namer := namespacedEntityNamer(customResourceName, customResourceNamespace)
 	bindingRule := consulApi.ACLBindingRule{}
 	bindingRule.ID = bindRuleAdapter.ID
-	bindingRule.BindName = fmt.Sprintf("%s_%s_%s", customResourceName, customResourceNamespace, bindRuleAdapter.BindName)
-	bindingRule.BindType = "role"
+	if bindRuleAdapter.BindType == string(consulApi.BindingRuleBindTypeTemplatedPolicy) {
+		// templated policies are built into Consul, so their names are not bound to the custom resource
+		bindingRule.BindName = bindRuleAdapter.BindName
+		bindingRule.BindType = consulApi.BindingRuleBindTypeTemplatedPolicy
+		bindingRule.BindVars = bindRuleAdapter.BindVars
+	} else {
+		bindingRule.BindName = namer.name(bindRuleAdapter.BindName)
+		bindingRule.BindType = consulApi.BindingRuleBindTypeRole
+	}
 	bindingRule.AuthMethod = authMethod
-	bindingRule.Description = bindRuleAdapter.Description
+	bindingRule.Description = namer.markDescription(bindRuleAdapter.Description)
 	bindingRule.Selector = fmt.Sprintf("serviceaccount.namespace==\"%s\" and serviceaccount.name==\"%s\"",
 		customResourceNamespace,
 		bindRuleAdapter.ServiceAccountName)
@@ -0,0 +1,99 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+)
+
+// ConsulACLValidator rejects ConsulACL resources which can not be applied in their namespace
+type ConsulACLValidator struct {
+	Client client.Reader
+}
+
+//+kubebuilder:webhook:path=/validate-netcracker-com-v1-consulacl,mutating=false,failurePolicy=fail,sideEffects=None,groups=netcracker.com,resources=consulacls,verbs=create;update,versions=v1,name=vconsulacl.netcracker.com,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the validating webhook for ConsulACL in the Manager.
+func (v *ConsulACLValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&consulaclv1.ConsulACL{}).
+		WithValidator(v).
+		Complete()
+}
+
+// SetupConversionWebhookWithManager registers the webhook which converts ConsulACL between v1alpha1 and v1 in the Manager.
+// The validating webhook registers it as well.
+func SetupConversionWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&consulaclv1.ConsulACL{}).
+		Complete()
+}
+
+func (v *ConsulACLValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(ctx, obj)
+}
+
+func (v *ConsulACLValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) error {
+	return v.validate(ctx, newObj)
+}
+
+func (v *ConsulACLValidator) ValidateDelete(context.Context, runtime.Object) error {
+	return nil
+}
+
+func (v *ConsulACLValidator) validate(ctx context.Context, obj runtime.Object) error {
+	cr, ok := obj.(*consulaclv1.ConsulACL)
+	if !ok {
+		return fmt.Errorf("expected ConsulACL, but got %T", obj)
+	}
+	aclConfig, err := getAclConfig(cr)
+	if err != nil {
+		return fmt.Errorf("can not parse ACL configuration: %w", err)
+	}
+	for _, bindRule := range aclConfig.BindRules {
+		if err = checkBindType(bindRule.BindType); err != nil {
+			return fmt.Errorf("binding rule for service account [%s] is invalid: %w", bindRule.ServiceAccountName, err)
+		}
+	}
+	usages, renderErrors, err := renderTemplatedPolicies(aclConfig, cr.Namespace)
+	if err != nil {
+		return err
+	}
+	if len(renderErrors) > 0 {
+		return fmt.Errorf("templated policies can not be checked: %s", formatGuardrailViolations(renderErrors))
+	}
+	violations, err := findGuardrailViolations(ctx, v.Client, cr.Namespace, append(usages.policies(), aclConfig.Policies...))
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("ACL configuration is forbidden by guardrails: %s", formatGuardrailViolations(violations))
+	}
+	quotaUsage, err := getQuotaUsage(ctx, v.Client, cr, aclConfig, false)
+	if err != nil {
+		return err
+	}
+	if exceeded := findExceededQuotas(quotaUsage); len(exceeded) > 0 {
+		return fmt.Errorf("quotas of the namespace are exceeded: %s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
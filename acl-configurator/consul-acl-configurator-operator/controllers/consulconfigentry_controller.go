@@ -0,0 +1,381 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+	consulApi "github.com/hashicorp/consul/api"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	"os"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strings"
+	"time"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	reasonInvalidEntry     = "InvalidEntry"
+	reasonKindNotAllowed   = "KindNotAllowed"
+	reasonEntryNotOwned    = "EntryNotOwned"
+	reasonServiceNotOwned  = "ServiceNotOwned"
+	reasonConcurrentChange = "ConcurrentChange"
+)
+
+// configEntryOwnerMeta is the meta key of config entries which contains the namespace and the name of ConsulConfigEntry
+var configEntryOwnerMeta = consulacl.GroupVersion.Group + "/owner"
+
+// allowedConfigEntryKinds restricts kinds of config entries, only kinds of a single service are allowed if it is empty
+var allowedConfigEntryKinds = parseAllowedConfigEntryKinds(os.Getenv("CONFIG_ENTRY_ALLOWED_KINDS"))
+
+// defaultConfigEntryKinds are allowed if allowed kinds are not set, they can not change cluster-wide configuration
+var defaultConfigEntryKinds = []string{
+	consulApi.ServiceDefaults, consulApi.ServiceRouter, consulApi.ServiceSplitter, consulApi.ServiceResolver,
+}
+
+// serviceConfigEntryKinds are named by the service they configure, so the service must be registered
+// by the namespace of ConsulConfigEntry
+var serviceConfigEntryKinds = []string{
+	consulApi.ServiceDefaults, consulApi.ServiceRouter, consulApi.ServiceSplitter, consulApi.ServiceResolver,
+	consulApi.IngressGateway, consulApi.TerminatingGateway, consulApi.APIGateway,
+}
+
+// ConsulConfigEntryReconciler reconciles a ConsulConfigEntry object. It writes config entries with check-and-set,
+// so changes made in Consul concurrently are not overwritten.
+type ConsulConfigEntryReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulconfigentries,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulconfigentries/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulconfigentries/finalizers,verbs=update
+
+func (r *ConsulConfigEntryReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling ConsulConfigEntry")
+
+	instance := &consulacl.ConsulConfigEntry{}
+	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	crUpdater := util.NewCustomResourceUpdater(r.Client, instance)
+	if instance.DeletionTimestamp.IsZero() {
+		if !util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulConfigEntry) {
+				controllerutil.AddFinalizer(cr, consulAclFinalizer)
+			})
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	} else {
+		if util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			return r.deleteConfigEntries(instance, crUpdater)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	source := newAuditSource(instance)
+	owner := fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	var condition metav1.Condition
+	var modifyIndex uint64
+	entry, err := buildConfigEntry(instance)
+	switch {
+	case err != nil:
+		condition = newSyncedCondition(false, reasonInvalidEntry, err.Error(), instance.Generation)
+		err = nil
+	case !isConfigEntryKindAllowed(entry.GetKind()):
+		condition = newSyncedCondition(false, reasonKindNotAllowed,
+			fmt.Sprintf("config entries of kind [%s] can not be managed by ConsulConfigEntry", entry.GetKind()), instance.Generation)
+	default:
+		var notOwned string
+		if util.Contains(entry.GetKind(), serviceConfigEntryKinds) {
+			notOwned, err = findNotOwnedService(instance.Namespace, entry.GetName(), "service")
+		}
+		if err == nil && notOwned != "" {
+			condition = newSyncedCondition(false, reasonServiceNotOwned, notOwned, instance.Generation)
+			break
+		}
+		// the previous config entry is deleted when the kind or the name is changed
+		if err == nil && instance.Status.Name != "" &&
+			(instance.Status.Kind != entry.GetKind() || instance.Status.Name != entry.GetName()) {
+			err = deleteConfigEntry(instance.Status.Kind, instance.Status.Name, owner, source)
+		}
+		var reason string
+		if err == nil {
+			reason, modifyIndex, err = applyConfigEntry(entry, owner, source)
+		}
+		if err == nil {
+			switch reason {
+			case "":
+				condition = newSyncedCondition(true, reasonApplied,
+					fmt.Sprintf("Config entry %s/%s is applied", entry.GetKind(), entry.GetName()), instance.Generation)
+			case reasonConcurrentChange:
+				condition = newSyncedCondition(false, reason, fmt.Sprintf("Config entry %s/%s is changed concurrently, retry later",
+					entry.GetKind(), entry.GetName()), instance.Generation)
+			default:
+				condition = newSyncedCondition(false, reasonEntryNotOwned, reason, instance.Generation)
+			}
+		}
+	}
+	if err != nil {
+		if _, ok := err.(net.Error); ok {
+			log.Error(err, "Error during connection to Consul")
+		} else {
+			log.Error(err, "Can not apply config entry")
+		}
+		condition = newSyncedCondition(false, reasonConsulError, err.Error(), instance.Generation)
+	}
+
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ConsulConfigEntry) {
+		if condition.Reason == reasonApplied {
+			cr.Status.Kind = entry.GetKind()
+			cr.Status.Name = entry.GetName()
+			cr.Status.ModifyIndex = modifyIndex
+		}
+		cr.Status.GeneralStatus = condition.Message
+		meta.SetStatusCondition(&cr.Status.Conditions, condition)
+	})
+	if err != nil {
+		log.Error(err, "Error occurred during custom resource status update")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	// invalid configuration is not retried until the custom resource is changed
+	if condition.Status != metav1.ConditionTrue && condition.Reason != reasonInvalidEntry && condition.Reason != reasonKindNotAllowed {
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	reqLogger.Info("Reconcile cycle succeeded")
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulConfigEntryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consulacl.ConsulConfigEntry{}, builder.WithPredicates(util.StatusPredicate())).
+		Complete(r)
+}
+
+func (r *ConsulConfigEntryReconciler) deleteConfigEntries(instance *consulacl.ConsulConfigEntry, crUpdater util.CustomResourceUpdater[*consulacl.ConsulConfigEntry]) (ctrl.Result, error) {
+	source := newAuditSource(instance)
+	owner := fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	if instance.Status.Name != "" {
+		if err := deleteConfigEntry(instance.Status.Kind, instance.Status.Name, owner, source); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	// the config entry can be written without the status update, for example, when the operator is restarted
+	if entry, err := buildConfigEntry(instance); err == nil && isConfigEntryKindAllowed(entry.GetKind()) {
+		if err = deleteConfigEntry(entry.GetKind(), entry.GetName(), owner, source); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	log.Info(fmt.Sprintf("Config entry for ConsulConfigEntry resource with name - [%s] is deleted", instance.Name))
+
+	err := crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulConfigEntry) {
+		controllerutil.RemoveFinalizer(cr, consulAclFinalizer)
+	})
+	return ctrl.Result{}, err
+}
+
+// buildConfigEntry decodes config entry from the specification and marks it with the owner meta.
+// Fields of Config must match Consul API types, Raw is decoded as "consul config write" does.
+func buildConfigEntry(instance *consulacl.ConsulConfigEntry) (consulApi.ConfigEntry, error) {
+	kind, name := instance.Spec.Kind, instance.Spec.Name
+	if name == "" {
+		name = instance.Name
+	}
+	raw := map[string]interface{}{}
+	switch {
+	case instance.Spec.Config != nil && instance.Spec.Raw != "":
+		return nil, fmt.Errorf("only one of config and raw can be set")
+	case instance.Spec.Config != nil:
+		typedEntry, err := consulApi.MakeConfigEntry(kind, name)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(instance.Spec.Config.Raw))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(typedEntry); err != nil {
+			return nil, fmt.Errorf("config does not match %s config entry: %w", kind, err)
+		}
+		if raw, err = toJSONMap(typedEntry); err != nil {
+			return nil, err
+		}
+	case instance.Spec.Raw != "":
+		if err := json.Unmarshal([]byte(instance.Spec.Raw), &raw); err != nil {
+			return nil, fmt.Errorf("raw is not a valid JSON object: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("one of config and raw must be set")
+	}
+
+	entryMeta := map[string]interface{}{}
+	for _, key := range []string{"Kind", "kind", "Name", "name", "Meta", "meta"} {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+		delete(raw, key)
+		switch strings.ToLower(key) {
+		case "kind":
+			if value != kind {
+				return nil, fmt.Errorf("kind [%v] of config entry differs from kind [%s] of the resource", value, kind)
+			}
+		case "name":
+			if value != name {
+				return nil, fmt.Errorf("name [%v] of config entry differs from name [%s] of the resource", value, name)
+			}
+		case "meta":
+			if values, ok := value.(map[string]interface{}); ok {
+				entryMeta = values
+			}
+		}
+	}
+	entryMeta[configEntryOwnerMeta] = fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	raw["Kind"], raw["Name"], raw["Meta"] = kind, name, entryMeta
+	return consulApi.DecodeConfigEntry(raw)
+}
+
+// applyConfigEntry writes the config entry if it differs from the existing one. The reason is returned
+// if the existing config entry is managed by someone else or it is changed concurrently.
+func applyConfigEntry(entry consulApi.ConfigEntry, owner string, source *auditSource) (string, uint64, error) {
+	existedEntry, err := readConfigEntry(entry.GetKind(), entry.GetName())
+	if err != nil {
+		return "", 0, err
+	}
+	var index uint64
+	action := "create"
+	if existedEntry != nil {
+		if existedOwner := existedEntry.GetMeta()[configEntryOwnerMeta]; existedOwner != owner {
+			if existedOwner == "" {
+				return fmt.Sprintf("config entry %s/%s is not managed by ConsulConfigEntry", entry.GetKind(), entry.GetName()), 0, nil
+			}
+			return fmt.Sprintf("config entry %s/%s is managed by ConsulConfigEntry %s", entry.GetKind(), entry.GetName(), existedOwner), 0, nil
+		}
+		index = existedEntry.GetModifyIndex()
+		action = "update"
+	}
+	oldLines, newLines := configEntryAuditLines(existedEntry), configEntryAuditLines(entry)
+	if existedEntry != nil && len(diffLines(oldLines, newLines)) == 0 {
+		return "", index, nil
+	}
+	written, _, err := consulClient.ConfigEntries().CAS(entry, index, &consulApi.WriteOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	if !written {
+		return reasonConcurrentChange, 0, nil
+	}
+	log.Info(fmt.Sprintf("Config entry %s/%s is %sd", entry.GetKind(), entry.GetName(), action))
+	recordAudit(source, action, auditEntityConfigEntry, entry.GetKind()+"/"+entry.GetName(), oldLines, newLines)
+	writtenEntry, err := readConfigEntry(entry.GetKind(), entry.GetName())
+	if err != nil || writtenEntry == nil {
+		return "", 0, err
+	}
+	return "", writtenEntry.GetModifyIndex(), nil
+}
+
+// deleteConfigEntry deletes the config entry if it is managed by the owner
+func deleteConfigEntry(kind string, name string, owner string, source *auditSource) error {
+	for attempt := 0; attempt < maxCASAttempts; attempt++ {
+		existedEntry, err := readConfigEntry(kind, name)
+		if err != nil {
+			return err
+		}
+		if existedEntry == nil || existedEntry.GetMeta()[configEntryOwnerMeta] != owner {
+			return nil
+		}
+		deleted, _, err := consulClient.ConfigEntries().DeleteCAS(kind, name, existedEntry.GetModifyIndex(), &consulApi.WriteOptions{})
+		if err != nil {
+			return err
+		}
+		if deleted {
+			log.Info(fmt.Sprintf("Config entry %s/%s is deleted", kind, name))
+			recordAudit(source, "delete", auditEntityConfigEntry, kind+"/"+name, configEntryAuditLines(existedEntry), nil)
+			return nil
+		}
+	}
+	return fmt.Errorf("config entry %s/%s is changed concurrently, retry later", kind, name)
+}
+
+// configEntryAuditLines returns top-level fields of config entry without indexes
+func configEntryAuditLines(entry consulApi.ConfigEntry) []string {
+	if entry == nil {
+		return nil
+	}
+	fields, err := toJSONMap(entry)
+	if err != nil {
+		return nil
+	}
+	delete(fields, "CreateIndex")
+	delete(fields, "ModifyIndex")
+	var lines []string
+	for key, value := range fields {
+		valueJSON, _ := json.Marshal(value)
+		lines = append(lines, fmt.Sprintf("%s = %s", key, valueJSON))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func toJSONMap(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+func isConfigEntryKindAllowed(kind string) bool {
+	// intentions are managed by ConsulIntention resources, so their sources are restricted to the namespace
+	if kind == consulApi.ServiceIntentions {
+		return false
+	}
+	if len(allowedConfigEntryKinds) == 0 {
+		return util.Contains(kind, defaultConfigEntryKinds)
+	}
+	return util.Contains(kind, allowedConfigEntryKinds)
+}
+
+func parseAllowedConfigEntryKinds(value string) []string {
+	var kinds []string
+	for _, kind := range strings.Split(value, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,492 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+	consulApi "github.com/hashicorp/consul/api"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	reasonInvalidService    = "InvalidService"
+	reasonServiceNotAllowed = "ServiceNotAllowed"
+	reasonNodesConflict     = "NodesConflict"
+
+	// externalServiceEntityName is the name of policy which allows the token of ConsulExternalService
+	// to register its nodes and service
+	externalServiceEntityName       = "external-service"
+	externalServiceTokenDescription = "Token of ConsulExternalService %s/%s"
+	// externalServiceOwnerMeta is the service meta key which contains the namespace and the name of ConsulExternalService
+	externalServiceOwnerMeta = "external-service-owner"
+
+	defaultExternalCheckInterval = 10 * time.Second
+	defaultExternalCheckTimeout  = 5 * time.Second
+)
+
+// externalNodeMeta marks nodes which are not run by Consul agents, so their checks are run by Consul External Service Monitor
+var externalNodeMeta = map[string]string{
+	"external-node":  "true",
+	"external-probe": "true",
+}
+
+// ConsulExternalServiceReconciler reconciles a ConsulExternalService object. Nodes and the service are registered
+// with the token of custom resource which policy allows writing only them, the policy is checked by guardrails
+// of the namespace.
+type ConsulExternalServiceReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulexternalservices,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulexternalservices/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulexternalservices/finalizers,verbs=update
+
+func (r *ConsulExternalServiceReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling ConsulExternalService")
+
+	instance := &consulacl.ConsulExternalService{}
+	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	crUpdater := util.NewCustomResourceUpdater(r.Client, instance)
+	if instance.DeletionTimestamp.IsZero() {
+		if !util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulExternalService) {
+				controllerutil.AddFinalizer(cr, consulAclFinalizer)
+			})
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	} else {
+		if util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			return r.deleteExternalService(instance, crUpdater)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	var condition metav1.Condition
+	var nodes []string
+	registrations, err := newExternalServiceRegistrations(instance)
+	if err != nil {
+		condition = newSyncedCondition(false, reasonInvalidService, err.Error(), instance.Generation)
+	} else {
+		policy := newExternalServicePolicy(instance, registrations)
+		var reason, message string
+		reason, message, err = r.checkExternalService(ctx, instance, policy)
+		if err == nil && reason != "" {
+			condition = newSyncedCondition(false, reason, message, instance.Generation)
+		} else if err == nil {
+			var conflicts []string
+			nodes, conflicts, err = r.applyExternalService(instance, crUpdater, policy, registrations)
+			if err == nil && len(conflicts) > 0 {
+				condition = newSyncedCondition(false, reasonNodesConflict, strings.Join(conflicts, "; "), instance.Generation)
+			} else if err == nil {
+				condition = newSyncedCondition(true, reasonApplied, fmt.Sprintf("Service [%s] is registered on %d nodes",
+					instance.Spec.Service, len(nodes)), instance.Generation)
+			}
+		}
+	}
+	if err != nil && condition.Type == "" {
+		if _, ok := err.(net.Error); ok {
+			log.Error(err, "Error during connection to Consul")
+		} else {
+			log.Error(err, "Can not register external service")
+		}
+		condition = newSyncedCondition(false, reasonConsulError, err.Error(), instance.Generation)
+	}
+
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ConsulExternalService) {
+		if nodes != nil {
+			cr.Status.Nodes = nodes
+			cr.Status.Service = cr.Spec.Service
+		}
+		cr.Status.GeneralStatus = condition.Message
+		meta.SetStatusCondition(&cr.Status.Conditions, condition)
+	})
+	if err != nil {
+		log.Error(err, "Error occurred during custom resource status update")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	if condition.Reason == reasonInvalidService {
+		return reconcile.Result{}, nil
+	}
+	// registrations are checked periodically, so nodes and services deregistered in Consul are restored
+	reqLogger.Info("Reconcile cycle succeeded")
+	return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulExternalServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consulacl.ConsulExternalService{}, builder.WithPredicates(util.StatusPredicate())).
+		Complete(r)
+}
+
+// checkExternalService returns the reason and the message if the service can not be registered by the namespace,
+// the policy of token is not allowed by guardrails or the token can not be created because of quotas
+func (r *ConsulExternalServiceReconciler) checkExternalService(ctx context.Context, instance *consulacl.ConsulExternalService,
+	policy ACLPolicyAdapter) (string, string, error) {
+	notAllowed, err := checkExternalServiceName(instance.Namespace, instance.Spec.Service)
+	if err != nil || notAllowed != "" {
+		return reasonServiceNotAllowed, notAllowed, err
+	}
+	violations, err := findGuardrailViolations(ctx, r.Client, instance.Namespace, []ACLPolicyAdapter{policy})
+	if err != nil || len(violations) > 0 {
+		return reasonServiceNotAllowed, violations[policy.Name], err
+	}
+	// the custom resource is counted by the namespace usage, so quotas are checked only before the token is created
+	if instance.Status.TokenAccessorID != "" {
+		return "", "", nil
+	}
+	usage, err := getNamespaceQuotaUsage(ctx, r.Client, instance.Namespace, func(*consulaclv1.ConsulACL) bool { return false })
+	if err != nil {
+		return "", "", err
+	}
+	if exceeded := findExceededQuotas(usage); len(exceeded) > 0 {
+		return reasonQuotaExceeded, fmt.Sprintf("Token is not created, quotas of the namespace are exceeded: %s",
+			strings.Join(exceeded, ", ")), nil
+	}
+	return "", "", nil
+}
+
+// applyExternalService deregisters the service from nodes which are not declared anymore with the current token,
+// then updates the policy of token and registers the service on declared nodes. Nodes which the service is registered on
+// and conflicts are returned.
+func (r *ConsulExternalServiceReconciler) applyExternalService(instance *consulacl.ConsulExternalService,
+	crUpdater util.CustomResourceUpdater[*consulacl.ConsulExternalService], policy ACLPolicyAdapter,
+	registrations []*consulApi.CatalogRegistration) ([]string, []string, error) {
+	source := newAuditSource(instance)
+	token, err := r.ensureToken(instance, crUpdater, policy, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	declared := map[string]bool{}
+	for _, registration := range registrations {
+		declared[registration.Node] = instance.Status.Service == "" || instance.Status.Service == instance.Spec.Service
+	}
+	for _, node := range instance.Status.Nodes {
+		if !declared[node] {
+			if err = deregisterExternalService(node, instance.Status.Service, token); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if _, _, err = applyPolicy(policy.ACLPolicy, source); err != nil {
+		return nil, nil, err
+	}
+
+	owner := fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	forceUpdate := isSpecChanged(instance.Status.Conditions, instance.Generation)
+	var nodes, conflicts []string
+	for _, registration := range registrations {
+		catalogNode, _, err := consulClient.Catalog().Node(registration.Node, &consulApi.QueryOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		var existedService *consulApi.AgentService
+		if catalogNode != nil && catalogNode.Node != nil {
+			if catalogNode.Node.Meta["external-node"] != "true" {
+				conflicts = append(conflicts, fmt.Sprintf("node [%s] is not an external node", registration.Node))
+				continue
+			}
+			existedService = catalogNode.Services[registration.Service.ID]
+			// the service of another owner on the same node would be redirected to the new address
+			otherService := findOtherOwnerService(catalogNode, owner)
+			if otherService != nil && catalogNode.Node.Address != registration.Address {
+				conflicts = append(conflicts, fmt.Sprintf("node [%s] has address [%s] used by service [%s] of [%s]",
+					registration.Node, catalogNode.Node.Address, otherService.ID, otherService.Meta[externalServiceOwnerMeta]))
+				continue
+			}
+		}
+		if existedService != nil && existedService.Meta[externalServiceOwnerMeta] != owner {
+			conflicts = append(conflicts, fmt.Sprintf("service [%s] on node [%s] is registered by [%s]",
+				registration.Service.ID, registration.Node, existedService.Meta[externalServiceOwnerMeta]))
+			continue
+		}
+		nodes = append(nodes, registration.Node)
+		if !forceUpdate && existedService != nil && catalogNode.Node.Address == registration.Address &&
+			isSameAgentService(existedService, registration.Service) {
+			continue
+		}
+		if _, err = consulClient.Catalog().Register(registration, &consulApi.WriteOptions{Token: token}); err != nil {
+			return nil, nil, err
+		}
+		log.Info(fmt.Sprintf("Service [%s] is registered on node [%s]", registration.Service.Service, registration.Node))
+	}
+	return nodes, conflicts, nil
+}
+
+// ensureToken returns the secret ID of token of custom resource. The token and its policy are created if they do not exist,
+// the accessor ID of created token is saved to the status immediately, so the token is not lost.
+func (r *ConsulExternalServiceReconciler) ensureToken(instance *consulacl.ConsulExternalService,
+	crUpdater util.CustomResourceUpdater[*consulacl.ConsulExternalService], policy ACLPolicyAdapter, source *auditSource) (string, error) {
+	if accessorID := instance.Status.TokenAccessorID; accessorID != "" {
+		token, _, err := aclClient.TokenRead(accessorID, &consulApi.QueryOptions{})
+		if err == nil && token != nil {
+			return token.SecretID, nil
+		}
+		if err != nil && !isErrNotFound(err) {
+			return "", err
+		}
+	}
+	if _, _, err := applyPolicy(policy.ACLPolicy, source); err != nil {
+		return "", err
+	}
+	token, _, err := aclClient.TokenCreate(&consulApi.ACLToken{
+		Description: fmt.Sprintf(externalServiceTokenDescription, instance.Namespace, instance.Name),
+		Policies:    []*consulApi.ACLTokenPolicyLink{{Name: policy.Name}},
+	}, &consulApi.WriteOptions{})
+	if err != nil {
+		return "", err
+	}
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ConsulExternalService) {
+		cr.Status.TokenAccessorID = token.AccessorID
+	})
+	if err != nil {
+		_, _ = aclClient.TokenDelete(token.AccessorID, &consulApi.WriteOptions{})
+		return "", err
+	}
+	instance.Status.TokenAccessorID = token.AccessorID
+	return token.SecretID, nil
+}
+
+func (r *ConsulExternalServiceReconciler) deleteExternalService(instance *consulacl.ConsulExternalService,
+	crUpdater util.CustomResourceUpdater[*consulacl.ConsulExternalService]) (ctrl.Result, error) {
+	if accessorID := instance.Status.TokenAccessorID; accessorID != "" {
+		token, _, err := aclClient.TokenRead(accessorID, &consulApi.QueryOptions{})
+		if err != nil && !isErrNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if token != nil {
+			for _, node := range instance.Status.Nodes {
+				if err = deregisterExternalService(node, instance.Status.Service, token.SecretID); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			if _, err = aclClient.TokenDelete(accessorID, &consulApi.WriteOptions{}); err != nil && !isErrNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	namer := namespacedEntityNamer(instance.Name, instance.Namespace)
+	if err := deletePolicy(namer.name(externalServiceEntityName), newAuditSource(instance)); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info(fmt.Sprintf("Service of ConsulExternalService resource with name - [%s] is deregistered", instance.Name))
+
+	err := crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulExternalService) {
+		controllerutil.RemoveFinalizer(cr, consulAclFinalizer)
+	})
+	return ctrl.Result{}, err
+}
+
+// deregisterExternalService deregisters the service from the node, the node is deregistered if it has no services left
+func deregisterExternalService(node string, service string, token string) error {
+	writeOptions := &consulApi.WriteOptions{Token: token}
+	catalogNode, _, err := consulClient.Catalog().Node(node, &consulApi.QueryOptions{Token: token})
+	if err != nil || catalogNode == nil || catalogNode.Node == nil {
+		return err
+	}
+	if _, ok := catalogNode.Services[service]; ok {
+		if _, err = consulClient.Catalog().Deregister(&consulApi.CatalogDeregistration{Node: node, ServiceID: service}, writeOptions); err != nil {
+			return err
+		}
+		delete(catalogNode.Services, service)
+		log.Info(fmt.Sprintf("Service [%s] is deregistered from node [%s]", service, node))
+	}
+	if len(catalogNode.Services) == 0 && catalogNode.Node.Meta["external-node"] == "true" {
+		if _, err = consulClient.Catalog().Deregister(&consulApi.CatalogDeregistration{Node: node}, writeOptions); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("External node [%s] is deregistered", node))
+	}
+	return nil
+}
+
+// newExternalServiceRegistrations builds a catalog registration of the service for each endpoint
+func newExternalServiceRegistrations(instance *consulacl.ConsulExternalService) ([]*consulApi.CatalogRegistration, error) {
+	if len(instance.Spec.Endpoints) == 0 {
+		return nil, fmt.Errorf("at least one endpoint must be declared")
+	}
+	serviceMeta := map[string]string{}
+	for key, value := range instance.Spec.Meta {
+		serviceMeta[key] = value
+	}
+	serviceMeta[externalServiceOwnerMeta] = fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	serviceMeta[kubernetesNamespaceMeta] = instance.Namespace
+
+	var registrations []*consulApi.CatalogRegistration
+	nodes := map[string]bool{}
+	for _, endpoint := range instance.Spec.Endpoints {
+		if nodes[endpoint.Node] {
+			return nil, fmt.Errorf("node [%s] is declared more than once", endpoint.Node)
+		}
+		nodes[endpoint.Node] = true
+		port := endpoint.Port
+		if port == 0 {
+			port = instance.Spec.Port
+		}
+		checks, err := newExternalServiceChecks(instance, endpoint, port)
+		if err != nil {
+			return nil, err
+		}
+		registrations = append(registrations, &consulApi.CatalogRegistration{
+			Node:     endpoint.Node,
+			Address:  endpoint.Address,
+			NodeMeta: externalNodeMeta,
+			Service: &consulApi.AgentService{
+				ID:      instance.Spec.Service,
+				Service: instance.Spec.Service,
+				Tags:    instance.Spec.Tags,
+				Address: endpoint.Address,
+				Port:    port,
+				Meta:    serviceMeta,
+			},
+			Checks: checks,
+		})
+	}
+	return registrations, nil
+}
+
+func newExternalServiceChecks(instance *consulacl.ConsulExternalService, endpoint consulacl.ExternalEndpoint, port int) (consulApi.HealthChecks, error) {
+	replacer := strings.NewReplacer("${address}", endpoint.Address, "${port}", strconv.Itoa(port))
+	var checks consulApi.HealthChecks
+	for _, check := range instance.Spec.Checks {
+		if (check.HTTP == "") == (check.TCP == "") {
+			return nil, fmt.Errorf("exactly one of http and tcp must be set in check [%s]", check.Name)
+		}
+		interval, err := parseCheckDuration(check.Interval, defaultExternalCheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("interval of check [%s] is invalid: %w", check.Name, err)
+		}
+		timeout, err := parseCheckDuration(check.Timeout, defaultExternalCheckTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("timeout of check [%s] is invalid: %w", check.Name, err)
+		}
+		checks = append(checks, &consulApi.HealthCheck{
+			Node:        endpoint.Node,
+			CheckID:     fmt.Sprintf("%s:%s", instance.Spec.Service, check.Name),
+			Name:        check.Name,
+			ServiceID:   instance.Spec.Service,
+			ServiceName: instance.Spec.Service,
+			Definition: consulApi.HealthCheckDefinition{
+				HTTP:             replacer.Replace(check.HTTP),
+				TCP:              replacer.Replace(check.TCP),
+				IntervalDuration: interval,
+				TimeoutDuration:  timeout,
+			},
+		})
+	}
+	return checks, nil
+}
+
+func parseCheckDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// checkExternalServiceName returns the reason if the namespace can not register the service. Services which are
+// registered in Consul catalog can be registered only if all their instances are registered by the namespace,
+// new services must be named with the "<namespace>-" prefix, so the token of ConsulExternalService can not write services
+// of other namespaces.
+func checkExternalServiceName(namespace string, service string) (string, error) {
+	owners, err := findServiceOwners(service)
+	if err != nil {
+		return "", err
+	}
+	if len(owners) == 0 && !strings.HasPrefix(service, namespace+"-") {
+		return fmt.Sprintf("service [%s] is not registered in Consul and its name does not start with [%s-]", service, namespace), nil
+	}
+	return findOtherServiceOwner(namespace, service, "service", owners), nil
+}
+
+// newExternalServicePolicy builds the policy of token of custom resource which allows writing only its nodes and service
+func newExternalServicePolicy(instance *consulacl.ConsulExternalService, registrations []*consulApi.CatalogRegistration) ACLPolicyAdapter {
+	namer := namespacedEntityNamer(instance.Name, instance.Namespace)
+	rules := []string{fmt.Sprintf("service %q {\n  policy = \"write\"\n}", instance.Spec.Service)}
+	for _, registration := range registrations {
+		rules = append(rules, fmt.Sprintf("node %q {\n  policy = \"write\"\n}", registration.Node))
+	}
+	sort.Strings(rules[1:])
+	policy := ACLPolicyAdapter{}
+	policy.Name = namer.name(externalServiceEntityName)
+	policy.Description = namer.description(fmt.Sprintf("Managed by ConsulExternalService %s/%s", instance.Namespace, instance.Name),
+		externalServiceEntityName)
+	policy.Rules = strings.Join(rules, "\n")
+	return policy
+}
+
+// findOtherOwnerService returns a service of the node which is not registered by the owner
+func findOtherOwnerService(catalogNode *consulApi.CatalogNode, owner string) *consulApi.AgentService {
+	var serviceIDs []string
+	for serviceID, service := range catalogNode.Services {
+		if service.Meta[externalServiceOwnerMeta] != owner {
+			serviceIDs = append(serviceIDs, serviceID)
+		}
+	}
+	if len(serviceIDs) == 0 {
+		return nil
+	}
+	sort.Strings(serviceIDs)
+	return catalogNode.Services[serviceIDs[0]]
+}
+
+func isSameAgentService(existed *consulApi.AgentService, desired *consulApi.AgentService) bool {
+	if existed.Address != desired.Address || existed.Port != desired.Port ||
+		strings.Join(existed.Tags, ",") != strings.Join(desired.Tags, ",") ||
+		len(existed.Meta) != len(desired.Meta) {
+		return false
+	}
+	for key, value := range desired.Meta {
+		if existed.Meta[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// isSpecChanged returns true if the specification is changed since the last successful reconciliation
+func isSpecChanged(conditions []metav1.Condition, generation int64) bool {
+	condition := meta.FindStatusCondition(conditions, conditionSynced)
+	return condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != generation
+}
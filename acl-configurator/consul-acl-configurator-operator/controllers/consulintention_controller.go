@@ -0,0 +1,314 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+	consulApi "github.com/hashicorp/consul/api"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strings"
+	"time"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	reasonSourcesNotOwned     = "SourcesNotOwned"
+	reasonDestinationNotOwned = "DestinationNotOwned"
+	reasonSourcesConflict     = "SourcesConflict"
+
+	// intentionSourceDescription marks sources of "service-intentions" config entries managed by ConsulIntention,
+	// so sources of different resources and sources created manually are not mixed
+	intentionSourceDescription = "Managed by ConsulIntention %s/%s"
+)
+
+// ConsulIntentionReconciler reconciles a ConsulIntention object. It manages sources of "service-intentions"
+// config entries, sources are restricted to services registered by the namespace of ConsulIntention,
+// destinations of other namespaces require approval.
+type ConsulIntentionReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulintentions,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulintentions/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulintentions/finalizers,verbs=update
+
+func (r *ConsulIntentionReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling ConsulIntention")
+
+	instance := &consulacl.ConsulIntention{}
+	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	crUpdater := util.NewCustomResourceUpdater(r.Client, instance)
+	if instance.DeletionTimestamp.IsZero() {
+		if !util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulIntention) {
+				controllerutil.AddFinalizer(cr, consulAclFinalizer)
+			})
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	} else {
+		if util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			return r.deleteIntentions(instance, crUpdater)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	source := newAuditSource(instance)
+	var condition metav1.Condition
+	var notApproved string
+	notOwned, err := findNotOwnedSources(instance.Namespace, instance.Spec.Sources)
+	if err == nil && len(notOwned) == 0 {
+		notApproved, err = checkIntentionDestination(instance)
+	}
+	if err == nil && len(notOwned) > 0 {
+		condition = newSyncedCondition(false, reasonSourcesNotOwned, strings.Join(notOwned, "; "), instance.Generation)
+	} else if err == nil && notApproved != "" {
+		condition = newSyncedCondition(false, reasonDestinationNotOwned, notApproved, instance.Generation)
+	} else if err == nil {
+		// sources are moved from the config entry of previous destination
+		if previous := instance.Status.Destination; previous != "" && previous != instance.Spec.Destination {
+			_, err = writeIntentionSources(instance, previous, nil, source)
+		}
+		var conflict string
+		if err == nil {
+			conflict, err = writeIntentionSources(instance, instance.Spec.Destination, newSourceIntentions(instance), source)
+		}
+		if err == nil && conflict != "" {
+			condition = newSyncedCondition(false, reasonSourcesConflict, conflict, instance.Generation)
+		} else if err == nil {
+			condition = newSyncedCondition(true, reasonApplied,
+				fmt.Sprintf("Sources are applied to intentions of service [%s]", instance.Spec.Destination), instance.Generation)
+		}
+	}
+	if err != nil {
+		if _, ok := err.(net.Error); ok {
+			log.Error(err, "Error during connection to Consul")
+		} else {
+			log.Error(err, "Can not apply intentions")
+		}
+		condition = newSyncedCondition(false, reasonConsulError, err.Error(), instance.Generation)
+	}
+
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ConsulIntention) {
+		if condition.Reason == reasonApplied {
+			cr.Status.Destination = cr.Spec.Destination
+		}
+		cr.Status.GeneralStatus = condition.Message
+		meta.SetStatusCondition(&cr.Status.Conditions, condition)
+	})
+	if err != nil {
+		log.Error(err, "Error occurred during custom resource status update")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	if condition.Status != metav1.ConditionTrue {
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	reqLogger.Info("Reconcile cycle succeeded")
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulIntentionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consulacl.ConsulIntention{}, builder.WithPredicates(util.StatusPredicate())).
+		Complete(r)
+}
+
+func (r *ConsulIntentionReconciler) deleteIntentions(instance *consulacl.ConsulIntention, crUpdater util.CustomResourceUpdater[*consulacl.ConsulIntention]) (ctrl.Result, error) {
+	source := newAuditSource(instance)
+	destinations := []string{instance.Spec.Destination}
+	if instance.Status.Destination != "" && instance.Status.Destination != instance.Spec.Destination {
+		destinations = append(destinations, instance.Status.Destination)
+	}
+	for _, destination := range destinations {
+		if _, err := writeIntentionSources(instance, destination, nil, source); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	log.Info(fmt.Sprintf("All intention sources for ConsulIntention resource with name - [%s] are deleted", instance.Name))
+
+	err := crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulIntention) {
+		controllerutil.RemoveFinalizer(cr, consulAclFinalizer)
+	})
+	return ctrl.Result{}, err
+}
+
+// findNotOwnedSources returns reasons for sources which are not registered in Consul catalog
+// by the namespace of ConsulIntention
+func findNotOwnedSources(namespace string, sources []consulacl.IntentionSource) ([]string, error) {
+	var notOwned []string
+	for _, intentionSource := range sources {
+		reason, err := findNotOwnedService(namespace, intentionSource.Name, "source service")
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			notOwned = append(notOwned, reason)
+		}
+	}
+	return notOwned, nil
+}
+
+// checkIntentionDestination returns the reason if the destination is not registered in Consul catalog
+// by the namespace of ConsulIntention and the specification is not approved
+func checkIntentionDestination(instance *consulacl.ConsulIntention) (string, error) {
+	reason, err := findNotOwnedService(instance.Namespace, instance.Spec.Destination, "destination service")
+	if err != nil || reason == "" {
+		return "", err
+	}
+	if approvalKey == "" {
+		return fmt.Sprintf("%s, sources can not be approved because the approval key is not configured", reason), nil
+	}
+	specHash, err := getIntentionSpecHash(instance)
+	if err != nil {
+		return "", err
+	}
+	if isApproved(instance, specHash) {
+		return "", nil
+	}
+	return fmt.Sprintf("%s, sources require the %s annotation signed for spec hash %s", reason, approvalAnnotation, specHash), nil
+}
+
+// getIntentionSpecHash returns SHA-256 hash of the namespace, the destination and sources of ConsulIntention,
+// so the approval can not be reused by other namespaces and for other sources
+func getIntentionSpecHash(instance *consulacl.ConsulIntention) (string, error) {
+	specBytes, err := json.Marshal(struct {
+		Namespace   string                      `json:"namespace"`
+		Destination string                      `json:"destination"`
+		Sources     []consulacl.IntentionSource `json:"sources"`
+	}{instance.Namespace, instance.Spec.Destination, instance.Spec.Sources})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(specBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func newSourceIntentions(instance *consulacl.ConsulIntention) []*consulApi.SourceIntention {
+	marker := fmt.Sprintf(intentionSourceDescription, instance.Namespace, instance.Name)
+	var sources []*consulApi.SourceIntention
+	for _, intentionSource := range instance.Spec.Sources {
+		description := marker
+		if intentionSource.Description != "" {
+			description = fmt.Sprintf("%s. %s", strings.TrimSuffix(intentionSource.Description, "."), marker)
+		}
+		sources = append(sources, &consulApi.SourceIntention{
+			Name:        intentionSource.Name,
+			Action:      consulApi.IntentionAction(intentionSource.Action),
+			Type:        consulApi.IntentionSourceConsul,
+			Description: description,
+		})
+	}
+	return sources
+}
+
+// writeIntentionSources replaces sources of ConsulIntention in "service-intentions" config entry of the destination
+// with desired sources. Other sources of the config entry are kept, the config entry is deleted when no sources are left.
+// The config entry is written with check-and-set, so concurrent changes are not overwritten.
+// If a desired source is managed by someone else, the conflict is returned and nothing is written.
+func writeIntentionSources(instance *consulacl.ConsulIntention, destination string,
+	desired []*consulApi.SourceIntention, source *auditSource) (string, error) {
+	marker := fmt.Sprintf(intentionSourceDescription, instance.Namespace, instance.Name)
+	desiredNames := map[string]bool{}
+	for _, desiredSource := range desired {
+		desiredNames[desiredSource.Name] = true
+	}
+	for attempt := 0; attempt < maxCASAttempts; attempt++ {
+		configEntry, err := readConfigEntry(consulApi.ServiceIntentions, destination)
+		if err != nil {
+			return "", err
+		}
+		entry, _ := configEntry.(*consulApi.ServiceIntentionsConfigEntry)
+		var index uint64
+		var sources, owned []*consulApi.SourceIntention
+		if entry != nil {
+			index = entry.ModifyIndex
+			for _, existedSource := range entry.Sources {
+				if strings.HasSuffix(existedSource.Description, marker) {
+					owned = append(owned, existedSource)
+					continue
+				}
+				if desiredNames[existedSource.Name] {
+					return fmt.Sprintf("source [%s] of service [%s] intentions is not managed by this resource, its description is %q",
+						existedSource.Name, destination, existedSource.Description), nil
+				}
+				sources = append(sources, existedSource)
+			}
+		}
+		oldLines, newLines := intentionAuditLines(owned), intentionAuditLines(desired)
+		if len(diffLines(oldLines, newLines)) == 0 {
+			return "", nil
+		}
+		sources = append(sources, desired...)
+		var written bool
+		action := "update"
+		if len(sources) == 0 {
+			action = "delete"
+			written, _, err = consulClient.ConfigEntries().DeleteCAS(consulApi.ServiceIntentions, destination, index, &consulApi.WriteOptions{})
+		} else {
+			if entry == nil {
+				action = "create"
+				entry = &consulApi.ServiceIntentionsConfigEntry{Kind: consulApi.ServiceIntentions, Name: destination}
+			}
+			entry.Sources = sources
+			written, _, err = consulClient.ConfigEntries().CAS(entry, index, &consulApi.WriteOptions{})
+		}
+		if err != nil {
+			return "", err
+		}
+		if written {
+			log.Info(fmt.Sprintf("Intentions of service [%s] are updated by ConsulIntention [%s/%s]",
+				destination, instance.Namespace, instance.Name))
+			recordAudit(source, action, auditEntityIntentions, destination, oldLines, newLines)
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("intentions of service [%s] are changed concurrently, retry later", destination)
+}
+
+func intentionAuditLines(sources []*consulApi.SourceIntention) []string {
+	var lines []string
+	for _, intentionSource := range sources {
+		lines = append(lines, fmt.Sprintf("%s = %q (%s)", intentionSource.Name, intentionSource.Action, intentionSource.Description))
+	}
+	sort.Strings(lines)
+	return lines
+}
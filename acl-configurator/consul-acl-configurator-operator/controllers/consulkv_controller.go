@@ -0,0 +1,461 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"net"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strings"
+	"time"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	reasonInvalidKeys      = "InvalidKeys"
+	reasonPrefixNotAllowed = "PrefixNotAllowed"
+	reasonKeysConflict     = "KeysConflict"
+)
+
+// ConsulKVReconciler reconciles a ConsulKV object. Keys are written under the "<namespace>/" prefix with check-and-set,
+// so keys which are changed outside of ConsulKV are not overwritten.
+type ConsulKVReconciler struct {
+	Client client.Client
+	// APIReader reads ConfigMaps and Secrets, so they are not cached by the Manager
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulkvs,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulkvs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulkvs/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+
+func (r *ConsulKVReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling ConsulKV")
+
+	instance := &consulacl.ConsulKV{}
+	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	crUpdater := util.NewCustomResourceUpdater(r.Client, instance)
+	if instance.DeletionTimestamp.IsZero() {
+		if !util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulKV) {
+				controllerutil.AddFinalizer(cr, consulAclFinalizer)
+			})
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	} else {
+		if util.Contains(consulAclFinalizer, instance.GetFinalizers()) {
+			return r.deleteKeys(instance, crUpdater)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	source := newAuditSource(instance)
+	prefix := getKVPrefix(instance)
+	var condition metav1.Condition
+	var ownedKeys map[string]uint64
+	desired, err := r.getDesiredKeys(ctx, instance, prefix)
+	if err != nil {
+		condition = newSyncedCondition(false, reasonInvalidKeys, err.Error(), instance.Generation)
+		err = nil
+	} else {
+		var reason string
+		if reason, err = checkKVPrefix(ctx, r.Client, instance.Namespace, prefix); err == nil && reason != "" {
+			condition = newSyncedCondition(false, reasonPrefixNotAllowed, reason, instance.Generation)
+		} else if err == nil {
+			var conflicts []string
+			var protected protectedKeys
+			protected, err = r.getKeysOfOtherResources(ctx, instance)
+			if err == nil {
+				ownedKeys, conflicts, err = applyKeys(prefix, desired, instance.Status.Keys, protected, instance.Spec.Prune, source)
+			}
+			if err == nil && len(conflicts) > 0 {
+				condition = newSyncedCondition(false, reasonKeysConflict, strings.Join(conflicts, "; "), instance.Generation)
+			} else if err == nil {
+				condition = newSyncedCondition(true, reasonApplied,
+					fmt.Sprintf("%d keys are applied under prefix [%s]", len(desired), prefix), instance.Generation)
+			}
+		}
+	}
+	if err != nil {
+		if _, ok := err.(net.Error); ok {
+			log.Error(err, "Error during connection to Consul")
+		} else {
+			log.Error(err, "Can not apply keys")
+		}
+		condition = newSyncedCondition(false, reasonConsulError, err.Error(), instance.Generation)
+	}
+
+	err = crUpdater.UpdateStatusWithRetry(func(cr *consulacl.ConsulKV) {
+		if ownedKeys != nil {
+			cr.Status.Keys = ownedKeys
+		}
+		cr.Status.GeneralStatus = condition.Message
+		meta.SetStatusCondition(&cr.Status.Conditions, condition)
+	})
+	if err != nil {
+		log.Error(err, "Error occurred during custom resource status update")
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	// ConfigMaps and Secrets are not watched, so keys loaded from them are synchronized periodically
+	if condition.Status != metav1.ConditionTrue || len(instance.Spec.From) > 0 {
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	reqLogger.Info("Reconcile cycle succeeded")
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulKVReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consulacl.ConsulKV{}, builder.WithPredicates(util.StatusPredicate())).
+		Complete(r)
+}
+
+func (r *ConsulKVReconciler) deleteKeys(instance *consulacl.ConsulKV, crUpdater util.CustomResourceUpdater[*consulacl.ConsulKV]) (ctrl.Result, error) {
+	source := newAuditSource(instance)
+	protected, err := r.getKeysOfOtherResources(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	for key, index := range instance.Status.Keys {
+		if protected.contains(key) {
+			continue
+		}
+		if _, err = deleteKey(&consulApi.KVPair{Key: key, ModifyIndex: index}, source); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if instance.Spec.Prune && strings.Trim(instance.Spec.Prefix, "/") != "" {
+		if _, err = pruneKeys(getKVPrefix(instance), nil, protected, source); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	log.Info(fmt.Sprintf("All keys for ConsulKV resource with name - [%s] are deleted", instance.Name))
+
+	err = crUpdater.UpdateWithRetry(func(cr *consulacl.ConsulKV) {
+		controllerutil.RemoveFinalizer(cr, consulAclFinalizer)
+	})
+	return ctrl.Result{}, err
+}
+
+// protectedKeys are keys of other ConsulKV resources of the namespace which are not deleted or pruned
+type protectedKeys struct {
+	keys map[string]bool
+	// prefixes protect all keys of resources which desired keys can not be read
+	prefixes []string
+}
+
+func (p protectedKeys) contains(key string) bool {
+	if p.keys[key] {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// getKeysOfOtherResources returns keys declared by specifications of other ConsulKV resources of the namespace
+// and keys which they still own, so they are not deleted or pruned. If declared keys of a resource can not be read,
+// for example its ConfigMap does not exist, all keys under its prefix are protected. Resources which are being deleted
+// do not protect keys.
+func (r *ConsulKVReconciler) getKeysOfOtherResources(ctx context.Context, instance *consulacl.ConsulKV) (protectedKeys, error) {
+	protected := protectedKeys{keys: map[string]bool{}}
+	instances := &consulacl.ConsulKVList{}
+	if err := r.Client.List(ctx, instances, client.InNamespace(instance.Namespace)); err != nil {
+		return protected, err
+	}
+	for i := range instances.Items {
+		other := &instances.Items[i]
+		if other.Name == instance.Name || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		for key := range other.Status.Keys {
+			protected.keys[key] = true
+		}
+		prefix := getKVPrefix(other)
+		desired, err := r.getDesiredKeys(ctx, other, prefix)
+		if err != nil {
+			protected.prefixes = append(protected.prefixes, prefix)
+			continue
+		}
+		for key := range desired {
+			protected.keys[key] = true
+		}
+	}
+	return protected, nil
+}
+
+// getKVPrefix returns the full prefix of keys of ConsulKV which starts with its namespace and ends with "/"
+func getKVPrefix(instance *consulacl.ConsulKV) string {
+	prefix := strings.Trim(instance.Spec.Prefix, "/")
+	if prefix == "" {
+		return instance.Namespace + "/"
+	}
+	return fmt.Sprintf("%s/%s/", instance.Namespace, prefix)
+}
+
+// getDesiredKeys returns values of declared and loaded keys by their full paths
+func (r *ConsulKVReconciler) getDesiredKeys(ctx context.Context, instance *consulacl.ConsulKV, prefix string) (map[string]string, error) {
+	if err := validateKVPath(instance.Spec.Prefix, true); err != nil {
+		return nil, fmt.Errorf("prefix [%s] is invalid: %w", instance.Spec.Prefix, err)
+	}
+	// prune with the empty prefix would delete all keys of the namespace
+	if instance.Spec.Prune && strings.Trim(instance.Spec.Prefix, "/") == "" {
+		return nil, fmt.Errorf("prune requires a non-empty prefix")
+	}
+	desired := map[string]string{}
+	addKey := func(key string, value string, origin string) error {
+		if err := validateKVPath(key, false); err != nil {
+			return fmt.Errorf("key [%s] from %s is invalid: %w", key, origin, err)
+		}
+		if _, ok := desired[prefix+key]; ok {
+			return fmt.Errorf("key [%s] from %s is declared more than once", key, origin)
+		}
+		desired[prefix+key] = value
+		return nil
+	}
+	for _, pair := range instance.Spec.Keys {
+		if err := addKey(pair.Key, pair.Value, "keys"); err != nil {
+			return nil, err
+		}
+	}
+	for _, kvSource := range instance.Spec.From {
+		data := map[string]string{}
+		var origin string
+		switch {
+		case kvSource.ConfigMapName != "" && kvSource.SecretName == "":
+			origin = fmt.Sprintf("ConfigMap [%s]", kvSource.ConfigMapName)
+			configMap := &corev1.ConfigMap{}
+			if err := r.APIReader.Get(ctx, types.NamespacedName{Name: kvSource.ConfigMapName, Namespace: instance.Namespace}, configMap); err != nil {
+				return nil, fmt.Errorf("can not read %s: %w", origin, err)
+			}
+			for key, value := range configMap.Data {
+				data[key] = value
+			}
+			for key, value := range configMap.BinaryData {
+				data[key] = string(value)
+			}
+		case kvSource.SecretName != "" && kvSource.ConfigMapName == "":
+			origin = fmt.Sprintf("Secret [%s]", kvSource.SecretName)
+			secret := &corev1.Secret{}
+			if err := r.APIReader.Get(ctx, types.NamespacedName{Name: kvSource.SecretName, Namespace: instance.Namespace}, secret); err != nil {
+				return nil, fmt.Errorf("can not read %s: %w", origin, err)
+			}
+			for key, value := range secret.Data {
+				data[key] = string(value)
+			}
+		default:
+			return nil, fmt.Errorf("exactly one of configMapName and secretName must be set in from")
+		}
+		for key, value := range data {
+			if err := addKey(kvSource.Prefix+key, value, origin); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return desired, nil
+}
+
+// validateKVPath checks that the path does not leave the prefix of ConsulKV
+func validateKVPath(path string, allowEmpty bool) error {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		if allowEmpty {
+			return nil
+		}
+		return fmt.Errorf("path is empty")
+	}
+	if !allowEmpty && strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path must be relative")
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("path must not contain empty, \".\" or \"..\" segments")
+		}
+	}
+	return nil
+}
+
+// checkKVPrefix returns the reason if guardrails of the namespace do not allow writing keys under the prefix
+func checkKVPrefix(ctx context.Context, reader client.Reader, namespace string, prefix string) (string, error) {
+	guardrails, err := getNamespaceGuardrails(ctx, reader, namespace)
+	if err != nil || len(guardrails) == 0 {
+		return "", err
+	}
+	rule := aclrules.Rule{Resource: "key", Segment: prefix, Prefix: true, Access: aclrules.AccessWrite}
+	if !isRuleAllowed(rule, guardrails, namespace) {
+		return fmt.Sprintf("rule '%s' is not allowed by guardrails", rule), nil
+	}
+	return "", nil
+}
+
+// applyKeys writes desired keys and deletes owned keys which are not desired anymore. Keys are written with check-and-set,
+// existing keys are overwritten only if they are not changed since the last write of ConsulKV. Keys which already have
+// desired values are adopted. Owned keys which are not desired anymore are deleted, and if prune is set, other keys under
+// the prefix are deleted, except protected ones in both cases. Modify indexes of owned keys and conflicts are returned.
+func applyKeys(prefix string, desired map[string]string, owned map[string]uint64, protected protectedKeys, prune bool,
+	source *auditSource) (map[string]uint64, []string, error) {
+	result := map[string]uint64{}
+	var conflicts []string
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := desired[key]
+		existedPair, _, err := consulClient.KV().Get(key, &consulApi.QueryOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		index, isOwned := owned[key]
+		if existedPair != nil && string(existedPair.Value) == value {
+			result[key] = existedPair.ModifyIndex
+			continue
+		}
+		if existedPair != nil && (!isOwned || existedPair.ModifyIndex != index) {
+			conflicts = append(conflicts, fmt.Sprintf("key [%s] is changed outside of ConsulKV", key))
+			if isOwned {
+				result[key] = index
+			}
+			continue
+		}
+		pair := &consulApi.KVPair{Key: key, Value: []byte(value)}
+		action := "create"
+		if existedPair != nil {
+			pair.ModifyIndex = existedPair.ModifyIndex
+			action = "update"
+		}
+		written, _, err := consulClient.KV().CAS(pair, &consulApi.WriteOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		if !written {
+			conflicts = append(conflicts, fmt.Sprintf("key [%s] is changed concurrently", key))
+			if isOwned {
+				result[key] = index
+			}
+			continue
+		}
+		recordAudit(source, action, auditEntityKey, key, kvAuditLines(existedPair), kvAuditLines(pair))
+		writtenPair, _, err := consulClient.KV().Get(key, &consulApi.QueryOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		if writtenPair != nil {
+			result[key] = writtenPair.ModifyIndex
+		}
+	}
+
+	for key, index := range owned {
+		if _, ok := desired[key]; ok || protected.contains(key) {
+			continue
+		}
+		deleted, err := deleteKey(&consulApi.KVPair{Key: key, ModifyIndex: index}, source)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !deleted {
+			conflicts = append(conflicts, fmt.Sprintf("key [%s] is not declared anymore, but it is changed outside of ConsulKV and is kept", key))
+		}
+	}
+	if prune {
+		pruneConflicts, err := pruneKeys(prefix, desired, protected, source)
+		if err != nil {
+			return nil, nil, err
+		}
+		conflicts = append(conflicts, pruneConflicts...)
+	}
+	return result, conflicts, nil
+}
+
+// pruneKeys deletes keys under the prefix which are neither desired nor protected
+func pruneKeys(prefix string, desired map[string]string, protected protectedKeys, source *auditSource) ([]string, error) {
+	pairs, _, err := consulClient.KV().List(prefix, &consulApi.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, pair := range pairs {
+		if _, ok := desired[pair.Key]; ok || protected.contains(pair.Key) {
+			continue
+		}
+		deleted, err := deleteKey(pair, source)
+		if err != nil {
+			return nil, err
+		}
+		if !deleted {
+			conflicts = append(conflicts, fmt.Sprintf("key [%s] is changed concurrently and is not pruned", pair.Key))
+		}
+	}
+	return conflicts, nil
+}
+
+// deleteKey deletes the key if it is not changed since the modify index of pair. Absent keys are considered deleted.
+func deleteKey(pair *consulApi.KVPair, source *auditSource) (bool, error) {
+	existedPair, _, err := consulClient.KV().Get(pair.Key, &consulApi.QueryOptions{})
+	if err != nil || existedPair == nil {
+		return err == nil, err
+	}
+	if existedPair.ModifyIndex != pair.ModifyIndex {
+		log.Info(fmt.Sprintf("Key [%s] is changed outside of ConsulKV and is not deleted", pair.Key))
+		return false, nil
+	}
+	deleted, _, err := consulClient.KV().DeleteCAS(existedPair, &consulApi.WriteOptions{})
+	if err == nil && deleted {
+		recordAudit(source, "delete", auditEntityKey, pair.Key, kvAuditLines(existedPair), nil)
+	}
+	return deleted, err
+}
+
+// kvAuditLines returns a hash of the value, so values of secrets are not written to audit
+func kvAuditLines(pair *consulApi.KVPair) []string {
+	if pair == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("value sha256 = %x", sha256.Sum256(pair.Value))}
+}
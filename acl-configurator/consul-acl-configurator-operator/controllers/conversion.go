@@ -0,0 +1,321 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+)
+
+const (
+	webhookCACertKey       = "ca.crt"
+	webhookCertValidity    = 10 * 365 * 24 * time.Hour
+	webhookCertRenewBefore = 30 * 24 * time.Hour
+	webhookServicePort     = 443
+	conversionWebhookPath  = "/convert"
+)
+
+var consulACLCRDName = "consulacls." + consulaclv1.GroupVersion.Group
+
+// ConversionManager configures the conversion webhook of ConsulACL custom resource definition and migrates
+// ConsulACL resources stored in previous versions to the storage version. When the service name is empty,
+// the webhook certificate and the custom resource definition are expected to be configured externally.
+type ConversionManager struct {
+	Client      client.Client
+	Reader      client.Reader
+	Namespace   string
+	ServiceName string
+	Period      time.Duration
+
+	caBundle []byte
+	migrated bool
+}
+
+func NewConversionManager(client client.Client, reader client.Reader, namespace string, serviceName string) *ConversionManager {
+	return &ConversionManager{
+		Client:      client,
+		Reader:      reader,
+		Namespace:   namespace,
+		ServiceName: serviceName,
+		Period:      time.Second * time.Duration(periodTime),
+	}
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update
+
+// SetupCertificate writes the serving certificate of the webhook server to the directory. The certificate is kept
+// in the Secret, so it does not change on restarts of the operator. It must be called before the Manager is started.
+func (m *ConversionManager) SetupCertificate(ctx context.Context, certDir string) error {
+	if m.ServiceName == "" {
+		return nil
+	}
+	secret, err := m.ensureCertificateSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(certDir, 0700); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(certDir, corev1.TLSCertKey), secret.Data[corev1.TLSCertKey], 0600); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(certDir, corev1.TLSPrivateKeyKey), secret.Data[corev1.TLSPrivateKeyKey], 0600); err != nil {
+		return err
+	}
+	m.caBundle = secret.Data[webhookCACertKey]
+	return nil
+}
+
+// Start configures the conversion webhook and migrates ConsulACL resources each period until the context is closed.
+// The configuration is repeated, because it is reset when the custom resource definition is applied again.
+func (m *ConversionManager) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.Period)
+	defer ticker.Stop()
+	for {
+		if err := m.configureConversionWebhook(ctx); err != nil {
+			log.Error(err, "Can not configure conversion webhook of ConsulACL custom resource definition")
+		} else if !m.migrated {
+			if err = m.migrateStorageVersion(ctx); err != nil {
+				log.Error(err, "Can not migrate ConsulACL resources to the storage version")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *ConversionManager) ensureCertificateSecret(ctx context.Context) (*corev1.Secret, error) {
+	secretName := m.ServiceName + "-cert"
+	var secret *corev1.Secret
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return errors.IsConflict(err) || errors.IsAlreadyExists(err)
+	}, func() error {
+		secret = &corev1.Secret{}
+		err := m.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: m.Namespace}, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		exists := err == nil
+		if exists && isCertificateValid(secret.Data[corev1.TLSCertKey]) {
+			return nil
+		}
+		data, err := generateWebhookCertificate(m.ServiceName, m.Namespace)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: m.Namespace},
+				Type:       corev1.SecretTypeTLS,
+				Data:       data,
+			}
+			return m.Client.Create(ctx, secret)
+		}
+		secret.Data = data
+		return m.Client.Update(ctx, secret)
+	})
+	if err == nil {
+		log.Info(fmt.Sprintf("Webhook certificate is taken from secret [%s]", secretName))
+	}
+	return secret, err
+}
+
+func isCertificateValid(certPEM []byte) bool {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	return err == nil && time.Now().Add(webhookCertRenewBefore).Before(cert.NotAfter)
+}
+
+// generateWebhookCertificate generates self-signed CA and the serving certificate for DNS names of the service
+func generateWebhookCertificate(serviceName string, namespace string) (map[string][]byte, error) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(webhookCertValidity)
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: serviceName + "-ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("%s.%s.svc", serviceName, namespace)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{serviceName, serviceName + "." + namespace, host, host + ".cluster.local"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		webhookCACertKey:        pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;update
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update
+
+// configureConversionWebhook points the conversion webhook of ConsulACL custom resource definition to the service
+// of the operator with the CA bundle of webhook certificate
+func (m *ConversionManager) configureConversionWebhook(ctx context.Context) error {
+	if m.ServiceName == "" {
+		return nil
+	}
+	path := conversionWebhookPath
+	port := int32(webhookServicePort)
+	desired := &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: m.Namespace,
+					Name:      m.ServiceName,
+					Path:      &path,
+					Port:      &port,
+				},
+				CABundle: m.caBundle,
+			},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := m.Reader.Get(ctx, types.NamespacedName{Name: consulACLCRDName}, crd); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(crd.Spec.Conversion, desired) {
+			return nil
+		}
+		crd.Spec.Conversion = desired
+		if err := m.Client.Update(ctx, crd); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("Conversion webhook of custom resource definition [%s] is configured", consulACLCRDName))
+		return nil
+	})
+}
+
+// migrateStorageVersion rewrites ConsulACL resources, so they are stored in the storage version, and removes
+// previous versions from stored versions of the custom resource definition
+func (m *ConversionManager) migrateStorageVersion(ctx context.Context) error {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := m.Reader.Get(ctx, types.NamespacedName{Name: consulACLCRDName}, crd); err != nil {
+		return err
+	}
+	storageVersion := ""
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			storageVersion = version.Name
+		}
+	}
+	if storageVersion != consulaclv1.GroupVersion.Version {
+		return fmt.Errorf("storage version of custom resource definition [%s] is [%s], but [%s] is expected",
+			consulACLCRDName, storageVersion, consulaclv1.GroupVersion.Version)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		m.migrated = true
+		return nil
+	}
+
+	instances := &consulaclv1.ConsulACLList{}
+	if err := m.Reader.List(ctx, instances); err != nil {
+		return err
+	}
+	// previous versions are removed from stored versions only if all resources are rewritten
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			// the update without changes stores the resource in the storage version
+			err := m.Client.Update(ctx, instance)
+			if errors.IsConflict(err) {
+				if getErr := m.Reader.Get(ctx, client.ObjectKeyFromObject(instance), instance); getErr != nil {
+					return getErr
+				}
+			}
+			return err
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("can not migrate ConsulACL %s/%s: %w", instance.Namespace, instance.Name, err)
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := m.Reader.Get(ctx, types.NamespacedName{Name: consulACLCRDName}, crd); err != nil {
+			return err
+		}
+		crd.Status.StoredVersions = []string{storageVersion}
+		return m.Client.Status().Update(ctx, crd)
+	})
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("%d ConsulACL resources are migrated to storage version [%s]", len(instances.Items), storageVersion))
+	m.migrated = true
+	return nil
+}
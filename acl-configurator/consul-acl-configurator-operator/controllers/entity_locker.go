@@ -0,0 +1,93 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	policyLockPrefix      = "policy/"
+	roleLockPrefix        = "role/"
+	bindingRuleLockPrefix = "binding-rule/"
+)
+
+var entityLocks = newEntityLocker()
+
+// entityLocker serializes changes of Consul ACL entities with the same name between parallel reconcile workers
+type entityLocker struct {
+	mutex sync.Mutex
+	locks map[string]*entityLock
+}
+
+type entityLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+func newEntityLocker() *entityLocker {
+	return &entityLocker{locks: map[string]*entityLock{}}
+}
+
+// Lock acquires locks for all given keys and returns a function which releases them.
+// Keys are acquired in sorted order, so two workers locking intersecting sets of keys can not deadlock.
+func (el *entityLocker) Lock(keys ...string) func() {
+	keys = uniqueSorted(keys)
+	for _, key := range keys {
+		el.acquire(key).mutex.Lock()
+	}
+	return func() {
+		for i := len(keys) - 1; i >= 0; i-- {
+			el.release(keys[i])
+		}
+	}
+}
+
+func (el *entityLocker) acquire(key string) *entityLock {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+	lock, ok := el.locks[key]
+	if !ok {
+		lock = &entityLock{}
+		el.locks[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+func (el *entityLocker) release(key string) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+	lock := el.locks[key]
+	lock.mutex.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(el.locks, key)
+	}
+}
+
+func uniqueSorted(keys []string) []string {
+	set := map[string]struct{}{}
+	var result []string
+	for _, key := range keys {
+		if _, ok := set[key]; !ok {
+			set[key] = struct{}{}
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
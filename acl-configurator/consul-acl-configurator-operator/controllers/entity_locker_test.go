@@ -0,0 +1,102 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUniqueSorted(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "no keys", keys: nil, want: nil},
+		{name: "sorted", keys: []string{"role/b", "policy/a"}, want: []string{"policy/a", "role/b"}},
+		{name: "duplicates", keys: []string{"policy/a", "role/b", "policy/a"}, want: []string{"policy/a", "role/b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := uniqueSorted(test.keys); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("uniqueSorted returned %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEntityLockerLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers [][]string
+	}{
+		{name: "same key", workers: [][]string{{"policy/a"}, {"policy/a"}, {"policy/a"}}},
+		{name: "duplicate keys", workers: [][]string{{"policy/a", "policy/a"}, {"policy/a"}}},
+		{name: "intersecting keys in different order", workers: [][]string{{"policy/a", "role/b"}, {"role/b", "policy/a"}, {"role/b", "binding-rule/c", "policy/a"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			locker := newEntityLocker()
+			// counters are changed without synchronization, so parallel access is reported by the race detector,
+			// and each worker checks that nobody else holds its keys
+			holders := map[string]*int{}
+			for _, keys := range test.workers {
+				for _, key := range keys {
+					holders[key] = new(int)
+				}
+			}
+			var wg sync.WaitGroup
+			errs := make(chan string, len(test.workers)*100)
+			for _, keys := range test.workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 100; i++ {
+						unlock := locker.Lock(keys...)
+						for _, key := range uniqueSorted(keys) {
+							if *holders[key]++; *holders[key] != 1 {
+								errs <- key + " is locked by several workers"
+							}
+						}
+						for _, key := range uniqueSorted(keys) {
+							*holders[key]--
+						}
+						unlock()
+					}
+				}()
+			}
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("workers are deadlocked")
+			}
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+			if len(locker.locks) != 0 {
+				t.Errorf("locks are not released: %v", locker.locks)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
+)
+
+const (
+	// maxEntityNameLength is the limit of Consul for names of policies and roles
+	maxEntityNameLength = 128
+	// entityNameHashLength is the length of hash suffix which is added to sanitized names
+	entityNameHashLength = 8
+	originalNamePrefix   = "Original name: "
+	// ownerMarkerPrefix is followed by "<namespace>/<name>" of the owner in descriptions of entities created for
+	// ConsulACL resources and service accounts, so entities created by hand are never treated as orphaned
+	ownerMarkerPrefix = "Managed by Consul ACL Configurator for "
+)
+
+// entityNamer converts names of entities from ACL configuration to names of Consul ACL entities.
+// All create, lookup and delete operations must use the same namer, so they refer to the same entities.
+type entityNamer struct {
+	prefix string
+	// owner is "<namespace>/<name>" of the owner which is marked in descriptions, global entities are not marked
+	owner string
+}
+
+// namespacedEntityNamer prefixes names of entities with the name and the namespace of custom resource
+func namespacedEntityNamer(name string, namespace string) entityNamer {
+	return entityNamer{prefix: fmt.Sprintf("%s_%s_", name, namespace), owner: fmt.Sprintf("%s/%s", namespace, name)}
+}
+
+// ConsulEntityName returns the name of Consul policy or role which is created for the entity of ConsulACL resource,
+// or of ClusterConsulACL resource if the namespace is empty
+func ConsulEntityName(name string, namespace string, entityName string) string {
+	if namespace == "" {
+		return globalEntityNamer.name(entityName)
+	}
+	return namespacedEntityNamer(name, namespace).name(entityName)
+}
+
+// globalEntityNamer keeps names of entities as is, it is used for cluster-wide entities
+var globalEntityNamer = entityNamer{}
+
+// fullName returns the name of entity before sanitization
+func (n entityNamer) fullName(entityName string) string {
+	return n.prefix + entityName
+}
+
+// name returns the name of Consul ACL entity
+func (n entityNamer) name(entityName string) string {
+	return sanitizeEntityName(n.fullName(entityName))
+}
+
+// description adds the full name of entity to its description if the name is changed by sanitization
+// and marks the owner of entity
+func (n entityNamer) description(description string, entityName string) string {
+	fullName := n.fullName(entityName)
+	if sanitizeEntityName(fullName) != fullName {
+		if description == "" {
+			description = originalNamePrefix + fullName
+		} else {
+			description = fmt.Sprintf("%s. %s%s", strings.TrimSuffix(description, "."), originalNamePrefix, fullName)
+		}
+	}
+	return n.markDescription(description)
+}
+
+// markDescription adds the owner marker to the description, the marker is always the last part of description
+func (n entityNamer) markDescription(description string) string {
+	if n.owner == "" {
+		return description
+	}
+	if description == "" {
+		return ownerMarkerPrefix + n.owner
+	}
+	return fmt.Sprintf("%s. %s%s", strings.TrimSuffix(description, "."), ownerMarkerPrefix, n.owner)
+}
+
+// parseOwnerMarker returns the owner which is marked in the description of entity
+func parseOwnerMarker(description string) (entityOwner, bool) {
+	index := strings.LastIndex(description, ownerMarkerPrefix)
+	if index < 0 {
+		return entityOwner{}, false
+	}
+	parts := strings.SplitN(description[index+len(ownerMarkerPrefix):], "/", 2)
+	if len(parts) != 2 || len(validation.IsDNS1123Label(parts[0])) > 0 || len(validation.IsDNS1123Subdomain(parts[1])) > 0 {
+		return entityOwner{}, false
+	}
+	return entityOwner{name: parts[1], namespace: parts[0]}, true
+}
+
+// sanitizeEntityName makes the name valid for Consul. Characters other than letters, digits, "-" and "_" are
+// replaced with "-" and the name is truncated to the Consul limit. If the name is changed, a hash of the original
+// name is appended, so different names are not mapped to the same one. Valid names are returned as is.
+func sanitizeEntityName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+	if sanitized == name && len(name) <= maxEntityNameLength && name != "" {
+		return name
+	}
+	hash := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(hash[:])[:entityNameHashLength]
+	maxLength := maxEntityNameLength - entityNameHashLength - 1
+	if len(sanitized) > maxLength {
+		sanitized = sanitized[:maxLength]
+	}
+	return sanitized + "-" + suffix
+}
@@ -0,0 +1,152 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeEntityName(t *testing.T) {
+	longName := strings.Repeat("a", maxEntityNameLength+1)
+	tests := []struct {
+		name       string
+		entityName string
+		want       string
+	}{
+		{name: "valid name is kept", entityName: "app_team-1", want: "app_team-1"},
+		{name: "longest valid name is kept", entityName: longName[1:], want: longName[1:]},
+		{name: "invalid characters are replaced", entityName: "app.team/1", want: "app-team-1-" + nameHash("app.team/1")},
+		{name: "long name is truncated", entityName: longName, want: longName[:maxEntityNameLength-entityNameHashLength-1] + "-" + nameHash(longName)},
+		{name: "empty name", entityName: "", want: "-" + nameHash("")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := sanitizeEntityName(test.entityName)
+			if got != test.want {
+				t.Errorf("sanitizeEntityName(%q) returned %q, want %q", test.entityName, got, test.want)
+			}
+			if len(got) > maxEntityNameLength {
+				t.Errorf("sanitized name is longer than %d characters: %d", maxEntityNameLength, len(got))
+			}
+		})
+	}
+}
+
+func TestSanitizeEntityNameKeepsNamesDistinct(t *testing.T) {
+	tests := []struct {
+		name  string
+		first string
+		other string
+	}{
+		{name: "different invalid characters", first: "app.read", other: "app/read"},
+		{name: "invalid and replacement characters", first: "app.read", other: "app-read"},
+		{name: "different tails of long names", first: strings.Repeat("a", 200) + "1", other: strings.Repeat("a", 200) + "2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if sanitizeEntityName(test.first) == sanitizeEntityName(test.other) {
+				t.Errorf("names %q and %q are sanitized to the same name", test.first, test.other)
+			}
+		})
+	}
+}
+
+func TestEntityNamer(t *testing.T) {
+	tests := []struct {
+		name            string
+		namer           entityNamer
+		entityName      string
+		description     string
+		wantName        string
+		wantDescription string
+	}{
+		{
+			name:  "global name is kept",
+			namer: globalEntityNamer, entityName: "read", description: "Reads keys.",
+			wantName: "read", wantDescription: "Reads keys.",
+		},
+		{
+			name:  "prefixed name",
+			namer: entityNamer{prefix: "acl_team_"}, entityName: "read", description: "Reads keys.",
+			wantName: "acl_team_read", wantDescription: "Reads keys.",
+		},
+		{
+			name:  "sanitized name without description",
+			namer: entityNamer{prefix: "acl_team_"}, entityName: "read.keys",
+			wantName: "acl_team_read-keys-" + nameHash("acl_team_read.keys"), wantDescription: "Original name: acl_team_read.keys",
+		},
+		{
+			name:  "sanitized name with description",
+			namer: entityNamer{prefix: "acl_team_"}, entityName: "read.keys", description: "Reads keys.",
+			wantName:        "acl_team_read-keys-" + nameHash("acl_team_read.keys"),
+			wantDescription: "Reads keys. Original name: acl_team_read.keys",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.namer.name(test.entityName); got != test.wantName {
+				t.Errorf("name returned %q, want %q", got, test.wantName)
+			}
+			if got := test.namer.description(test.description, test.entityName); got != test.wantDescription {
+				t.Errorf("description returned %q, want %q", got, test.wantDescription)
+			}
+		})
+	}
+}
+
+func nameHash(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(hash[:])[:entityNameHashLength]
+}
+
+func TestParseOwnerMarker(t *testing.T) {
+	namer := namespacedEntityNamer("acl", "team")
+	tests := []struct {
+		name        string
+		description string
+		wantOwner   entityOwner
+		wantOk      bool
+	}{
+		{name: "marked empty description", description: namer.markDescription(""), wantOwner: entityOwner{name: "acl", namespace: "team"}, wantOk: true},
+		{name: "marked description", description: namer.markDescription("Reads keys."), wantOwner: entityOwner{name: "acl", namespace: "team"}, wantOk: true},
+		{
+			name:        "marked description of sanitized name",
+			description: namer.description("Reads keys.", "read.keys"),
+			wantOwner:   entityOwner{name: "acl", namespace: "team"},
+			wantOk:      true,
+		},
+		{
+			name:        "last marker wins",
+			description: namer.markDescription(ownerMarkerPrefix + "other/acl"),
+			wantOwner:   entityOwner{name: "acl", namespace: "team"},
+			wantOk:      true,
+		},
+		{name: "global entity is not marked", description: globalEntityNamer.description("Reads keys.", "read")},
+		{name: "entity created by hand", description: "Reads keys."},
+		{name: "marker without name", description: ownerMarkerPrefix + "team"},
+		{name: "marker with invalid namespace", description: ownerMarkerPrefix + "Team/acl"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owner, ok := parseOwnerMarker(test.description)
+			if ok != test.wantOk || owner != test.wantOwner {
+				t.Errorf("parseOwnerMarker(%q) returned %+v, %t, want %+v, %t", test.description, owner, ok, test.wantOwner, test.wantOk)
+			}
+		})
+	}
+}
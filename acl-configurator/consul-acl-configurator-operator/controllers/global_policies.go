@@ -0,0 +1,88 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+// checkGlobalPolicyReferences returns the reason for each role which refers to a global policy that is not managed
+// by ClusterConsulACL resources or is not allowed by guardrails of the namespace
+func checkGlobalPolicyReferences(ctx context.Context, reader client.Reader, namespace string, roles []ACLRoleAdapter) (map[string]string, error) {
+	referenced := false
+	for _, role := range roles {
+		referenced = referenced || len(role.GlobalPolicyNames) > 0
+	}
+	if !referenced {
+		return nil, nil
+	}
+	globalPolicies, err := getGlobalPolicies(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	var referencedPolicies []ACLPolicyAdapter
+	for _, role := range roles {
+		for _, policyName := range role.GlobalPolicyNames {
+			if policy, ok := globalPolicies[policyName]; ok {
+				referencedPolicies = append(referencedPolicies, policy)
+			}
+		}
+	}
+	violations, err := findGuardrailViolations(ctx, reader, namespace, referencedPolicies)
+	if err != nil {
+		return nil, err
+	}
+	invalidRoles := map[string]string{}
+	for _, role := range roles {
+		for _, policyName := range role.GlobalPolicyNames {
+			if _, ok := globalPolicies[policyName]; !ok {
+				invalidRoles[role.Name] = fmt.Sprintf("global policy [%s] is not managed by ClusterConsulACL", policyName)
+				break
+			}
+			if reason, ok := violations[policyName]; ok {
+				invalidRoles[role.Name] = fmt.Sprintf("global policy [%s]: %s", policyName, reason)
+				break
+			}
+		}
+	}
+	return invalidRoles, nil
+}
+
+// getGlobalPolicies returns policies from all ClusterConsulACL resources by their names
+func getGlobalPolicies(ctx context.Context, reader client.Reader) (map[string]ACLPolicyAdapter, error) {
+	clusterACLList := &consulacl.ClusterConsulACLList{}
+	if err := reader.List(ctx, clusterACLList); err != nil {
+		return nil, err
+	}
+	globalPolicies := map[string]ACLPolicyAdapter{}
+	for _, clusterACL := range clusterACLList.Items {
+		if clusterACL.Spec.ACL == nil || !clusterACL.DeletionTimestamp.IsZero() {
+			continue
+		}
+		aclConfig, err := parseAclConfig(clusterACL.Spec.ACL.Json)
+		if err != nil {
+			// invalid configuration is reported in the status of ClusterConsulACL
+			continue
+		}
+		for _, policy := range aclConfig.Policies {
+			globalPolicies[policy.Name] = policy
+		}
+	}
+	return globalPolicies, nil
+}
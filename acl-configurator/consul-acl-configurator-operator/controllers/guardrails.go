@@ -0,0 +1,136 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	conditionGuardrailsSatisfied = "GuardrailsSatisfied"
+	reasonRulesAllowed           = "RulesAllowed"
+	reasonRulesForbidden         = "RulesForbidden"
+)
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulaclguardrails,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// findGuardrailViolations returns the reason of violation for each policy which rules are not allowed
+// by guardrails of the namespace. Policies are identified by their names from ACL configuration.
+func findGuardrailViolations(ctx context.Context, reader client.Reader, namespace string, policies []ACLPolicyAdapter) (map[string]string, error) {
+	guardrails, err := getNamespaceGuardrails(ctx, reader, namespace)
+	if err != nil || len(guardrails) == 0 {
+		return nil, err
+	}
+	violations := map[string]string{}
+	for _, policy := range policies {
+		rules, err := aclrules.Parse(policy.Rules)
+		if err != nil {
+			violations[policy.Name] = err.Error()
+			continue
+		}
+		for _, rule := range rules {
+			if !isRuleAllowed(rule, guardrails, namespace) {
+				violations[policy.Name] = fmt.Sprintf("rule '%s' is not allowed by guardrails", rule)
+				break
+			}
+		}
+	}
+	return violations, nil
+}
+
+func getNamespaceGuardrails(ctx context.Context, reader client.Reader, namespace string) ([]consulacl.ConsulACLGuardrail, error) {
+	guardrailList := &consulacl.ConsulACLGuardrailList{}
+	if err := reader.List(ctx, guardrailList); err != nil {
+		return nil, err
+	}
+	if len(guardrailList.Items) == 0 {
+		return nil, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+	var guardrails []consulacl.ConsulACLGuardrail
+	for _, guardrail := range guardrailList.Items {
+		selector := labels.Everything()
+		if guardrail.Spec.NamespaceSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(guardrail.Spec.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("guardrail [%s] has invalid namespace selector: %w", guardrail.Name, err)
+			}
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			guardrails = append(guardrails, guardrail)
+		}
+	}
+	return guardrails, nil
+}
+
+// isRuleAllowed checks that at least one of guardrails allows the rule. Deny rules are always allowed.
+func isRuleAllowed(rule aclrules.Rule, guardrails []consulacl.ConsulACLGuardrail, namespace string) bool {
+	if aclrules.AccessLevel(rule.Access) == aclrules.AccessLevel(aclrules.AccessDeny) {
+		return true
+	}
+	for _, guardrail := range guardrails {
+		for _, allowedRule := range guardrail.Spec.AllowedRules {
+			if isRuleAllowedBy(rule, allowedRule, namespace) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isRuleAllowedBy(rule aclrules.Rule, allowedRule consulacl.AllowedRule, namespace string) bool {
+	resource, prefix := aclrules.ParseBlock(allowedRule.Resource)
+	if resource != rule.Resource || (rule.Prefix && !prefix) {
+		return false
+	}
+	if aclrules.AccessLevel(rule.Access) > aclrules.AccessLevel(allowedRule.MaxAccess) {
+		return false
+	}
+	if len(allowedRule.Prefixes) == 0 {
+		return true
+	}
+	for _, allowedPrefix := range allowedRule.Prefixes {
+		allowedPrefix = strings.ReplaceAll(allowedPrefix, consulacl.GuardrailNamespacePlaceholder, namespace)
+		if strings.HasPrefix(rule.Segment, allowedPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatGuardrailViolations(violations map[string]string) string {
+	var messages []string
+	for policyName, reason := range violations {
+		messages = append(messages, fmt.Sprintf("policy [%s]: %s", policyName, reason))
+	}
+	sort.Strings(messages)
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,114 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+func TestIsRuleAllowed(t *testing.T) {
+	guardrails := []consulacl.ConsulACLGuardrail{
+		{Spec: consulacl.ConsulACLGuardrailSpec{AllowedRules: []consulacl.AllowedRule{
+			{Resource: "key_prefix", Prefixes: []string{"${namespace}/"}, MaxAccess: aclrules.AccessWrite},
+			{Resource: "service", MaxAccess: aclrules.AccessRead},
+		}}},
+		{Spec: consulacl.ConsulACLGuardrailSpec{AllowedRules: []consulacl.AllowedRule{
+			{Resource: "node_prefix", MaxAccess: aclrules.AccessList},
+		}}},
+	}
+	tests := []struct {
+		name string
+		rule aclrules.Rule
+		want bool
+	}{
+		{
+			name: "prefix within namespace",
+			rule: aclrules.Rule{Resource: "key", Segment: "team/app/", Prefix: true, Access: aclrules.AccessWrite},
+			want: true,
+		},
+		{
+			name: "exact rule allowed by prefix block",
+			rule: aclrules.Rule{Resource: "key", Segment: "team/config", Access: aclrules.AccessRead},
+			want: true,
+		},
+		{
+			name: "prefix of other namespace",
+			rule: aclrules.Rule{Resource: "key", Segment: "other/", Prefix: true, Access: aclrules.AccessRead},
+			want: false,
+		},
+		{
+			name: "prefix rule allowed only as exact block",
+			rule: aclrules.Rule{Resource: "service", Segment: "app", Prefix: true, Access: aclrules.AccessRead},
+			want: false,
+		},
+		{
+			name: "access above maximum",
+			rule: aclrules.Rule{Resource: "service", Segment: "app", Access: aclrules.AccessWrite},
+			want: false,
+		},
+		{
+			name: "list is above read",
+			rule: aclrules.Rule{Resource: "service", Segment: "app", Access: aclrules.AccessList},
+			want: false,
+		},
+		{
+			name: "rule allowed by second guardrail",
+			rule: aclrules.Rule{Resource: "node", Prefix: true, Access: aclrules.AccessList},
+			want: true,
+		},
+		{
+			name: "resource without allowed rules",
+			rule: aclrules.Rule{Resource: "operator", Access: aclrules.AccessRead},
+			want: false,
+		},
+		{
+			name: "deny is always allowed",
+			rule: aclrules.Rule{Resource: "operator", Access: aclrules.AccessDeny},
+			want: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isRuleAllowed(test.rule, guardrails, "team"); got != test.want {
+				t.Errorf("isRuleAllowed(%s) returned %t, want %t", test.rule, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatGuardrailViolations(t *testing.T) {
+	tests := []struct {
+		name       string
+		violations map[string]string
+		want       string
+	}{
+		{name: "no violations", violations: nil, want: ""},
+		{
+			name:       "sorted by policy",
+			violations: map[string]string{"write": "rule 'acl = \"write\"' is not allowed by guardrails", "read": "invalid"},
+			want:       "policy [read]: invalid; policy [write]: rule 'acl = \"write\"' is not allowed by guardrails",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := formatGuardrailViolations(test.violations); got != test.want {
+				t.Errorf("formatGuardrailViolations returned %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	consulApi "github.com/hashicorp/consul/api"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	globalManagementPolicyID = "00000000-0000-0000-0000-000000000001"
+
+	tokenPermissionsCacheTimeout = time.Minute
+)
+
+// TokenPermissionStatus describes whether the token of the operator has permissions to manage Consul ACLs
+type TokenPermissionStatus struct {
+	ACLWrite  bool      `json:"aclWrite"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthReport is a structured state of the operator connection to Consul
+type HealthReport struct {
+	ACLSystem   ACLSystemStatus       `json:"aclSystem"`
+	Permissions TokenPermissionStatus `json:"permissions"`
+}
+
+var tokenPermissionStatus TokenPermissionStatus
+var tokenPermissionStatusMutex sync.Mutex
+
+// CheckACLSystemReady is a readiness checker which fails when Consul ACL API is not reachable,
+// ACL system is not bootstrapped or the token of the operator is not valid
+func CheckACLSystemReady(_ *http.Request) error {
+	status := CheckACLSystem()
+	if !status.Ready {
+		return errors.New(status.Message)
+	}
+	return nil
+}
+
+// CheckTokenPermissionsReady is a readiness checker which fails when the token of the operator has no "acl = write" permission
+func CheckTokenPermissionsReady(_ *http.Request) error {
+	status := CheckTokenPermissions()
+	if !status.ACLWrite {
+		return errors.New(status.Message)
+	}
+	return nil
+}
+
+// CheckTokenPermissions resolves policies of the operator token including policies of its roles and checks that
+// one of them grants "acl = write". The result is cached because several requests to Consul are required.
+func CheckTokenPermissions() TokenPermissionStatus {
+	tokenPermissionStatusMutex.Lock()
+	defer tokenPermissionStatusMutex.Unlock()
+	if time.Since(tokenPermissionStatus.CheckedAt) < tokenPermissionsCacheTimeout {
+		return tokenPermissionStatus
+	}
+	tokenPermissionStatus = TokenPermissionStatus{CheckedAt: time.Now()}
+	aclWrite, err := hasACLWritePermission()
+	switch {
+	case err != nil:
+		tokenPermissionStatus.Message = fmt.Sprintf("Can not check permissions of the operator token: %s", err)
+	case !aclWrite:
+		tokenPermissionStatus.Message = `The operator token has no "acl = write" permission`
+	default:
+		tokenPermissionStatus.ACLWrite = true
+		tokenPermissionStatus.Message = `The operator token has "acl = write" permission`
+	}
+	return tokenPermissionStatus
+}
+
+func hasACLWritePermission() (bool, error) {
+	token, _, err := aclClient.TokenReadSelf(&consulApi.QueryOptions{})
+	if err != nil {
+		return false, err
+	}
+	var policyIDs []string
+	for _, link := range token.Policies {
+		policyIDs = append(policyIDs, link.ID)
+	}
+	for _, roleLink := range token.Roles {
+		role, _, err := aclClient.RoleRead(roleLink.ID, &consulApi.QueryOptions{})
+		if err != nil {
+			// reading of roles requires "acl = read", so the token can not have "acl = write" either
+			return false, nil
+		}
+		if role == nil {
+			continue
+		}
+		for _, link := range role.Policies {
+			policyIDs = append(policyIDs, link.ID)
+		}
+	}
+	for _, policyID := range policyIDs {
+		if policyID == globalManagementPolicyID {
+			return true, nil
+		}
+		policy, _, err := aclClient.PolicyRead(policyID, &consulApi.QueryOptions{})
+		if err != nil {
+			return false, nil
+		}
+		if policy != nil && hasACLWriteRule(policy.Rules) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func hasACLWriteRule(policyRules string) bool {
+	rules, err := aclrules.Parse(policyRules)
+	if err != nil {
+		return false
+	}
+	for _, rule := range rules {
+		if rule.Resource == "acl" && rule.Access == aclrules.AccessWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthReportHandler returns the structured health report in JSON format
+func HealthReportHandler(writer http.ResponseWriter, _ *http.Request) {
+	report := HealthReport{
+		ACLSystem:   CheckACLSystem(),
+		Permissions: CheckTokenPermissions(),
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	if !report.ACLSystem.Ready || !report.Permissions.ACLWrite {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(writer).Encode(report); err != nil {
+		log.Error(err, "Can not write health report")
+	}
+}
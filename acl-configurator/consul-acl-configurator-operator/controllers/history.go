@@ -0,0 +1,173 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+	"strconv"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	conditionRolledBack      = "RolledBack"
+	reasonRollbackApplied    = "RollbackApplied"
+	reasonNoRollback         = "NoRollback"
+	reasonInvalidGeneration  = "InvalidGeneration"
+	reasonGenerationNotFound = "GenerationNotFound"
+	historyConfigMapSuffix   = "-acl-history"
+	defaultHistoryLimit      = 10
+)
+
+var rollbackAnnotation = consulacl.GroupVersion.Group + "/rollback-to-generation"
+
+// historyLimit is the number of applied versions kept for each custom resource. Zero disables the history.
+var historyLimit = getHistoryLimit()
+
+func getHistoryLimit() int {
+	value, found := os.LookupEnv("HISTORY_LIMIT")
+	if !found || value == "" {
+		return defaultHistoryLimit
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		log.Error(err, fmt.Sprintf("HISTORY_LIMIT [%s] is invalid, default value %d is used", value, defaultHistoryLimit))
+		return defaultHistoryLimit
+	}
+	return limit
+}
+
+func getHistoryConfigMapName(cr *consulaclv1.ConsulACL) string {
+	return cr.Name + historyConfigMapSuffix
+}
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// getRollbackConfig returns ACL configuration stored for the generation from rollback annotation
+// and RolledBack condition. The configuration is nil if rollback is not requested or is not possible.
+func (r *ConsulACLReconciler) getRollbackConfig(ctx context.Context, cr *consulaclv1.ConsulACL) (*ACLConfig, metav1.Condition, error) {
+	condition := metav1.Condition{
+		Type:               conditionRolledBack,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cr.Generation,
+		Reason:             reasonNoRollback,
+		Message:            "ACL configuration of the current generation is applied",
+	}
+	value := cr.GetAnnotations()[rollbackAnnotation]
+	if value == "" {
+		return nil, condition, nil
+	}
+	generation, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		condition.Reason = reasonInvalidGeneration
+		condition.Message = fmt.Sprintf("The %s annotation must contain a generation number, but [%s] is specified",
+			rollbackAnnotation, value)
+		return nil, condition, nil
+	}
+	configMap, err := r.readHistory(ctx, cr)
+	if err != nil {
+		return nil, condition, err
+	}
+	storedConfig := ""
+	if configMap != nil {
+		storedConfig = configMap.Data[strconv.FormatInt(generation, 10)]
+	}
+	if storedConfig == "" {
+		condition.Reason = reasonGenerationNotFound
+		condition.Message = fmt.Sprintf("ACL configuration of generation %d is not found in the history, nothing is applied", generation)
+		return nil, condition, nil
+	}
+	aclConfig := &ACLConfig{}
+	if err = json.Unmarshal([]byte(storedConfig), aclConfig); err != nil {
+		return nil, condition, err
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = reasonRollbackApplied
+	condition.Message = fmt.Sprintf("ACL configuration of generation %d is applied instead of generation %d", generation, cr.Generation)
+	return aclConfig, condition, nil
+}
+
+// saveHistory stores the applied ACL configuration for the generation of custom resource
+// and removes the oldest versions which exceed the history limit
+func (r *ConsulACLReconciler) saveHistory(ctx context.Context, cr *consulaclv1.ConsulACL, aclConfig *ACLConfig) error {
+	if historyLimit == 0 {
+		return nil
+	}
+	configBytes, err := json.Marshal(aclConfig)
+	if err != nil {
+		return err
+	}
+	generation := strconv.FormatInt(cr.Generation, 10)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := r.readHistory(ctx, cr)
+		if err != nil {
+			return err
+		}
+		if configMap == nil {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: getHistoryConfigMapName(cr), Namespace: cr.Namespace},
+			}
+			if err = controllerutil.SetOwnerReference(cr, configMap, r.Scheme); err != nil {
+				return err
+			}
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		if configMap.Data[generation] == string(configBytes) {
+			return nil
+		}
+		configMap.Data[generation] = string(configBytes)
+		pruneHistory(configMap.Data, historyLimit)
+		if configMap.ResourceVersion == "" {
+			return r.Client.Create(ctx, configMap)
+		}
+		return r.Client.Update(ctx, configMap)
+	})
+}
+
+// readHistory reads the history ConfigMap bypassing the cache, so config maps are not cached by the operator
+func (r *ConsulACLReconciler) readHistory(ctx context.Context, cr *consulaclv1.ConsulACL) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.APIReader.Get(ctx, types.NamespacedName{Name: getHistoryConfigMapName(cr), Namespace: cr.Namespace}, configMap)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return configMap, err
+}
+
+func pruneHistory(data map[string]string, limit int) {
+	var generations []int64
+	for key := range data {
+		if generation, err := strconv.ParseInt(key, 10, 64); err == nil {
+			generations = append(generations, generation)
+		}
+	}
+	sort.Slice(generations, func(i, j int) bool { return generations[i] < generations[j] })
+	for i := 0; i < len(generations)-limit; i++ {
+		delete(data, strconv.FormatInt(generations[i], 10))
+	}
+}
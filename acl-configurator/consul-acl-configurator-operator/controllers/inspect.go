@@ -0,0 +1,206 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	consulApi "github.com/hashicorp/consul/api"
+	"regexp"
+	"strings"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+// states of Consul ACL entities reported by inspection
+const (
+	EntityInSync  = "in-sync"
+	EntityDrifted = "drifted"
+	EntityAbsent  = "absent"
+	EntityFrozen  = "frozen"
+)
+
+var selectorClauseRegexp = regexp.MustCompile(`^serviceaccount\.(namespace|name)\s*==\s*"([^"]*)"$`)
+
+// EntityState describes the desired Consul ACL entity of custom resource and its live counterpart in Consul
+type EntityState struct {
+	Type string `json:"type"`
+	// Name is the name of entity in ACL configuration
+	Name string `json:"name"`
+	// ConsulName is the name of entity in Consul, for binding rules it is the bind name
+	ConsulName string `json:"consulName"`
+	// Selector is the selector of binding rule
+	Selector string   `json:"selector,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	State    string   `json:"state"`
+	Diff     []string `json:"diff,omitempty"`
+}
+
+// InspectConsulACL compares ACL configuration of ConsulACL resource with Consul ACL entities without changing them.
+// Binding rules are looked up in the auth method.
+func InspectConsulACL(client *consulApi.ACL, cr *consulaclv1.ConsulACL, authMethod string) ([]EntityState, error) {
+	aclConfig, err := getAclConfig(cr)
+	if err != nil {
+		return nil, err
+	}
+	return inspectNamespacedACLConfig(client, authMethod, aclConfig, cr.Name, cr.Namespace)
+}
+
+// inspectNamespacedACLConfig compares policies, roles and binding rules of ACL configuration of ConsulACL resource
+// with Consul ACL entities
+func inspectNamespacedACLConfig(client *consulApi.ACL, authMethod string, aclConfig *ACLConfig,
+	customResourceName string, customResourceNamespace string) ([]EntityState, error) {
+	states, err := inspectACLConfig(client, aclConfig, namespacedEntityNamer(customResourceName, customResourceNamespace))
+	if err != nil {
+		return nil, err
+	}
+	bindingRuleStates, err := inspectBindingRules(client, authMethod, aclConfig.BindRules, customResourceName, customResourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return append(states, bindingRuleStates...), nil
+}
+
+// InspectClusterConsulACL compares ACL configuration of ClusterConsulACL resource with Consul ACL entities without changing them
+func InspectClusterConsulACL(client *consulApi.ACL, cr *consulacl.ClusterConsulACL) ([]EntityState, error) {
+	aclConfig, err := getClusterAclConfig(cr)
+	if err != nil {
+		return nil, err
+	}
+	return inspectACLConfig(client, aclConfig, globalEntityNamer)
+}
+
+// inspectACLConfig compares policies and roles of ACL configuration with Consul ACL entities.
+// Entities without names are not applied, so they are skipped.
+func inspectACLConfig(client *consulApi.ACL, aclConfig *ACLConfig, namer entityNamer) ([]EntityState, error) {
+	var states []EntityState
+	policyIDs := map[string]string{}
+	for _, policy := range aclConfig.Policies {
+		if policy.Name == "" {
+			continue
+		}
+		desiredPolicy := policy.ACLPolicy
+		desiredPolicy.Description = namer.description(policy.Description, policy.Name)
+		desiredPolicy.Name = namer.name(policy.Name)
+		existedPolicy, _, err := client.PolicyReadByName(desiredPolicy.Name, &consulApi.QueryOptions{})
+		if err != nil && !isErrNotFound(err) {
+			return nil, err
+		}
+		state := EntityState{Type: auditEntityPolicy, Name: policy.Name, ConsulName: desiredPolicy.Name}
+		if existedPolicy != nil {
+			policyIDs[desiredPolicy.Name] = existedPolicy.ID
+			state.ID = existedPolicy.ID
+			state.Diff = diffLines(policyAuditLines(existedPolicy), policyAuditLines(&desiredPolicy))
+		}
+		state.State = getEntityState(existedPolicy != nil, state.Diff, policy.Frozen)
+		states = append(states, state)
+	}
+	for _, roleAdapter := range aclConfig.Roles {
+		if roleAdapter.Name == "" {
+			continue
+		}
+		desiredRole := convertRoleAdapterToRole(roleAdapter, policyIDs, namer)
+		existedRole, _, err := client.RoleReadByName(desiredRole.Name, &consulApi.QueryOptions{})
+		if err != nil && !isErrNotFound(err) {
+			return nil, err
+		}
+		state := EntityState{Type: auditEntityRole, Name: roleAdapter.Name, ConsulName: desiredRole.Name}
+		if existedRole != nil {
+			state.ID = existedRole.ID
+			state.Diff = diffLines(roleAuditLines(existedRole), roleAuditLines(&desiredRole))
+		}
+		state.State = getEntityState(existedRole != nil, state.Diff, roleAdapter.Frozen)
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func inspectBindingRules(client *consulApi.ACL, authMethod string, bindRules []ACLBindingRuleAdapter,
+	customResourceName string, customResourceNamespace string) ([]EntityState, error) {
+	if len(bindRules) == 0 {
+		return nil, nil
+	}
+	existedRules, _, err := client.BindingRuleList(authMethod, &consulApi.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var states []EntityState
+	for _, bindRuleAdapter := range bindRules {
+		if bindRuleAdapter.BindName == "" {
+			continue
+		}
+		desiredRule := convertBindRuleAdapterToBindRule(bindRuleAdapter, customResourceName, customResourceNamespace)
+		state := EntityState{Type: auditEntityBindingRule, Name: bindRuleAdapter.BindName, ConsulName: desiredRule.BindName,
+			Selector: desiredRule.Selector, State: EntityAbsent}
+		for _, existedRule := range existedRules {
+			if containsBindingRule([]*consulApi.ACLBindingRule{existedRule}, desiredRule) {
+				state.ID = existedRule.ID
+				state.State = EntityInSync
+				break
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func getEntityState(exists bool, diff []string, frozen bool) string {
+	switch {
+	case !exists:
+		return EntityAbsent
+	case frozen:
+		return EntityFrozen
+	case len(diff) > 0:
+		return EntityDrifted
+	default:
+		return EntityInSync
+	}
+}
+
+// FindServiceAccountBindingRules returns binding rules of the auth method which selectors match the service account.
+// Only selectors built from equality checks of the namespace and the name of service account are evaluated,
+// binding rules with other selectors are returned separately as not evaluated.
+func FindServiceAccountBindingRules(client *consulApi.ACL, authMethod string, namespace string, name string) (
+	matched []*consulApi.ACLBindingRule, notEvaluated []*consulApi.ACLBindingRule, err error) {
+	bindingRules, _, err := client.BindingRuleList(authMethod, &consulApi.QueryOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	values := map[string]string{"namespace": namespace, "name": name}
+	for _, bindingRule := range bindingRules {
+		matches, evaluated := matchSelector(bindingRule.Selector, values)
+		if !evaluated {
+			notEvaluated = append(notEvaluated, bindingRule)
+		} else if matches {
+			matched = append(matched, bindingRule)
+		}
+	}
+	return matched, notEvaluated, nil
+}
+
+// matchSelector evaluates selectors in the "serviceaccount.namespace==\"x\" and serviceaccount.name==\"y\"" form
+func matchSelector(selector string, values map[string]string) (bool, bool) {
+	if strings.TrimSpace(selector) == "" {
+		return true, true
+	}
+	matches := true
+	for _, clause := range strings.Split(selector, " and ") {
+		match := selectorClauseRegexp.FindStringSubmatch(strings.TrimSpace(clause))
+		if match == nil {
+			return false, false
+		}
+		matches = matches && values[match[1]] == match[2]
+	}
+	return matches, true
+}
@@ -0,0 +1,240 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+	"time"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+)
+
+// templateLabel marks ConsulACL resources created from ConsulACLTemplate, its value is the name of template
+var templateLabel = consulacl.GroupVersion.Group + "/acl-template"
+
+// NamespaceReconciler creates ConsulACL resources from ConsulACLTemplate resources in selected namespaces
+type NamespaceReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+	// WatchNamespaces restricts namespaces where ConsulACL resources are created. Empty list allows all namespaces.
+	WatchNamespaces []string
+}
+
+//+kubebuilder:rbac:groups=netcracker.com,resources=consulacltemplates,verbs=get;list;watch
+
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Namespace", request.Name)
+	if len(r.WatchNamespaces) > 0 && !util.Contains(request.Name, r.WatchNamespaces) {
+		return reconcile.Result{}, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	err := r.Client.Get(ctx, request.NamespacedName, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// ConsulACL resources are deleted together with namespace
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !namespace.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	templates := &consulacl.ConsulACLTemplateList{}
+	if err = r.Client.List(ctx, templates); err != nil {
+		return reconcile.Result{}, err
+	}
+	desired := map[string]bool{}
+	failed := false
+	for i := range templates.Items {
+		template := &templates.Items[i]
+		if !template.DeletionTimestamp.IsZero() || !isTemplateSelected(template, namespace) {
+			continue
+		}
+		name := getTemplateConsulACLName(template)
+		desired[name] = true
+		if err = r.instantiateTemplate(ctx, template, namespace); err != nil {
+			reqLogger.Error(err, fmt.Sprintf("Can not create ConsulACL [%s] from template [%s]", name, template.Name))
+			failed = true
+		}
+	}
+
+	instances := &consulaclv1.ConsulACLList{}
+	if err = r.Client.List(ctx, instances, client.InNamespace(namespace.Name), client.HasLabels{templateLabel}); err != nil {
+		return reconcile.Result{}, err
+	}
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		if desired[instance.Name] || !instance.DeletionTimestamp.IsZero() {
+			continue
+		}
+		reqLogger.Info(fmt.Sprintf("Deleting ConsulACL [%s] created from template [%s]", instance.Name, instance.Labels[templateLabel]))
+		if err = r.Client.Delete(ctx, instance); err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, fmt.Sprintf("Can not delete ConsulACL [%s]", instance.Name))
+			failed = true
+		}
+	}
+	if failed {
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// instantiateTemplate creates or updates ConsulACL from the template. ConsulACL which is not created from
+// the template is not changed.
+func (r *NamespaceReconciler) instantiateTemplate(ctx context.Context, template *consulacl.ConsulACLTemplate, namespace *corev1.Namespace) error {
+	instance := &consulaclv1.ConsulACL{
+		ObjectMeta: metav1.ObjectMeta{Name: getTemplateConsulACLName(template), Namespace: namespace.Name},
+	}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(instance), instance)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && instance.Labels[templateLabel] != template.Name {
+		return fmt.Errorf("ConsulACL [%s] already exists and is not created from the template", instance.Name)
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, instance, func() error {
+		if instance.Labels == nil {
+			instance.Labels = map[string]string{}
+		}
+		instance.Labels[templateLabel] = template.Name
+		instance.Spec = consulaclv1.ConsulACLSpec{}
+		if acl := substituteTemplateACL(template.Spec.ACL, namespace); acl != nil {
+			spec, err := acl.ToSpec()
+			if err != nil {
+				return fmt.Errorf("ACL configuration of the template is invalid: %w", err)
+			}
+			instance.Spec = spec
+		}
+		return controllerutil.SetControllerReference(template, instance, r.Scheme)
+	})
+	return err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	labelsPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Only changes of labels can change the list of selected templates
+			return !labels.Equals(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+	}
+	templatePredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+		},
+	}
+	instancePredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Manual changes of ConsulACL created from template are reverted
+			return e.ObjectNew.GetLabels()[templateLabel] != "" &&
+				e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return e.Object.GetLabels()[templateLabel] != ""
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("namespace").
+		For(&corev1.Namespace{}, builder.WithPredicates(labelsPredicate)).
+		Watches(&source.Kind{Type: &consulacl.ConsulACLTemplate{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForAllNamespaces),
+			builder.WithPredicates(templatePredicate)).
+		Watches(&source.Kind{Type: &consulaclv1.ConsulACL{}},
+			handler.EnqueueRequestsFromMapFunc(requestForNamespace),
+			builder.WithPredicates(instancePredicate)).
+		Complete(r)
+}
+
+// requestsForAllNamespaces reconciles all namespaces on changes of template, so ConsulACL resources are created
+// in newly selected namespaces and are deleted from namespaces which are not selected anymore
+func (r *NamespaceReconciler) requestsForAllNamespaces(_ client.Object) []reconcile.Request {
+	namespaces := &corev1.NamespaceList{}
+	if err := r.Client.List(context.Background(), namespaces); err != nil {
+		log.Error(err, "Can not list namespaces")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, namespace := range namespaces.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: namespace.Name}})
+	}
+	return requests
+}
+
+func requestForNamespace(object client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: object.GetNamespace()}}}
+}
+
+func isTemplateSelected(template *consulacl.ConsulACLTemplate, namespace *corev1.Namespace) bool {
+	if template.Spec.NamespaceSelector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(template.Spec.NamespaceSelector)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("Namespace selector of template [%s] is invalid", template.Name))
+		return false
+	}
+	return selector.Matches(labels.Set(namespace.Labels))
+}
+
+func getTemplateConsulACLName(template *consulacl.ConsulACLTemplate) string {
+	if template.Spec.ConsulACLName != "" {
+		return template.Spec.ConsulACLName
+	}
+	return template.Name
+}
+
+// substituteTemplateACL replaces namespace placeholders in the name and in the ACL configuration of template
+func substituteTemplateACL(acl *consulacl.ACL, namespace *corev1.Namespace) *consulacl.ACL {
+	if acl == nil {
+		return nil
+	}
+	pairs := []string{consulacl.TemplateNamespacePlaceholder, namespace.Name}
+	for key, value := range namespace.Labels {
+		pairs = append(pairs, consulacl.TemplateNamespaceLabelPlaceholderPrefix+key+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+	return &consulacl.ACL{
+		Json: replacer.Replace(acl.Json),
+		Name: replacer.Replace(acl.Name),
+	}
+}
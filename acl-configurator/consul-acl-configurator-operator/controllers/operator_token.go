@@ -0,0 +1,281 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"time"
+
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	// operatorTokenRules grant management of ACL entities, intentions, config entries and keys, and reading of catalog
+	// to check namespaces of services
+	operatorTokenRules = `acl = "write"
+mesh = "write"
+key_prefix "" {
+  policy = "write"
+}
+service_prefix "" {
+  policy     = "write"
+  intentions = "write"
+}
+node_prefix "" {
+  policy = "read"
+}`
+	operatorTokenSecretKey      = "token"
+	operatorAccessorIDSecretKey = "accessorID"
+	// operatorPreviousAccessorIDSecretKey keeps the token replaced by the last rotation, it is deleted by the next rotation,
+	// so replicas which have not reloaded the secret yet can use it meanwhile
+	operatorPreviousAccessorIDSecretKey = "previousAccessorID"
+
+	defaultOperatorTokenSecretName     = "consul-acl-configurator-operator-token"
+	defaultOperatorTokenRotationPeriod = 24 * time.Hour
+	operatorTokenRetryPeriod           = time.Minute
+	operatorTokenReloadPeriod          = time.Minute
+)
+
+var operatorTokenRotatedAtAnnotation = consulacl.GroupVersion.Group + "/rotated-at"
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update
+
+// OperatorTokenManager replaces the bootstrap token with the operator token which has only permissions
+// required by the operator and rotates the operator token periodically. The current operator token is stored in Kubernetes secret,
+// and all replicas reload it from the secret with Reloader.
+type OperatorTokenManager struct {
+	Client         client.Client
+	Namespace      string
+	SecretName     string
+	PolicyName     string
+	RotationPeriod time.Duration
+}
+
+func NewOperatorTokenManager(client client.Client, namespace string) (*OperatorTokenManager, error) {
+	rotationPeriod := defaultOperatorTokenRotationPeriod
+	if value := os.Getenv("OPERATOR_TOKEN_ROTATION_PERIOD"); value != "" {
+		var err error
+		if rotationPeriod, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("OPERATOR_TOKEN_ROTATION_PERIOD is invalid: %w", err)
+		}
+	}
+	secretName := os.Getenv("OPERATOR_TOKEN_SECRET_NAME")
+	if secretName == "" {
+		secretName = defaultOperatorTokenSecretName
+	}
+	operatorName := os.Getenv("OPERATOR_NAME")
+	if operatorName == "" {
+		operatorName = "consul-acl-configurator-operator"
+	}
+	return &OperatorTokenManager{
+		Client:         client,
+		Namespace:      namespace,
+		SecretName:     secretName,
+		PolicyName:     fmt.Sprintf("%s-%s", operatorName, namespace),
+		RotationPeriod: rotationPeriod,
+	}, nil
+}
+
+// Bootstrap creates the operator policy and token with the bootstrap token, or reuses the operator token from secret
+// if it is still valid. After that the bootstrap token is not used anymore.
+func (m *OperatorTokenManager) Bootstrap(ctx context.Context) error {
+	// the policy is updated on each start, so permissions required by a new version are granted to the existing token
+	if _, _, err := applyPolicy(consulApi.ACLPolicy{
+		Name:        m.PolicyName,
+		Description: "Policy of Consul ACL Configurator operator",
+		Rules:       operatorTokenRules,
+	}, nil); err != nil {
+		return fmt.Errorf("can not apply operator policy: %w", err)
+	}
+	secret, err := m.readSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		token := string(secret.Data[operatorTokenSecretKey])
+		if _, _, err = aclClient.TokenReadSelf(&consulApi.QueryOptions{Token: token}); err == nil {
+			m.useToken(token)
+			log.Info(fmt.Sprintf("Operator token from secret [%s] is used", m.SecretName))
+			return nil
+		}
+		log.Info(fmt.Sprintf("Operator token from secret [%s] is not valid, new one will be created: %s", m.SecretName, err))
+	}
+	_, err = m.createToken(ctx, secret, "")
+	return err
+}
+
+// Start rotates the operator token each rotation period until the context is closed
+func (m *OperatorTokenManager) Start(ctx context.Context) error {
+	for {
+		wait := m.RotationPeriod
+		secret, err := m.readSecret(ctx)
+		if err != nil {
+			log.Error(err, "Can not read operator token secret")
+		} else if secret != nil {
+			if rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[operatorTokenRotatedAtAnnotation]); err == nil {
+				wait = time.Until(rotatedAt.Add(m.RotationPeriod))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+		if err = m.rotate(ctx); err != nil {
+			log.Error(err, "Can not rotate operator token")
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(operatorTokenRetryPeriod):
+			}
+		}
+	}
+}
+
+// rotate creates a new operator token and deletes the token replaced by the previous rotation.
+// The current token is kept for one more rotation period, because other replicas still use it until they reload the secret.
+func (m *OperatorTokenManager) rotate(ctx context.Context) error {
+	secret, err := m.readSecret(ctx)
+	if err != nil {
+		return err
+	}
+	currentAccessorID, previousAccessorID := "", ""
+	if secret != nil {
+		currentAccessorID = string(secret.Data[operatorAccessorIDSecretKey])
+		previousAccessorID = string(secret.Data[operatorPreviousAccessorIDSecretKey])
+	}
+	if _, err = m.createToken(ctx, secret, currentAccessorID); err != nil {
+		return err
+	}
+	if previousAccessorID != "" {
+		if _, err = aclClient.TokenDelete(previousAccessorID, &consulApi.WriteOptions{}); err != nil && !isErrNotFound(err) {
+			log.Error(err, fmt.Sprintf("Can not delete previous operator token with accessor ID [%s]", previousAccessorID))
+		}
+	}
+	log.Info("Operator token is rotated")
+	return nil
+}
+
+func (m *OperatorTokenManager) createToken(ctx context.Context, secret *corev1.Secret, previousAccessorID string) (*consulApi.ACLToken, error) {
+	token, _, err := aclClient.TokenCreate(&consulApi.ACLToken{
+		Description: fmt.Sprintf("Token of Consul ACL Configurator operator from namespace %s", m.Namespace),
+		Policies:    []*consulApi.ACLTokenPolicyLink{{Name: m.PolicyName}},
+	}, &consulApi.WriteOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can not create operator token: %w", err)
+	}
+	if err = m.writeSecret(ctx, secret, token, previousAccessorID); err != nil {
+		// the token is not saved, so it should not remain in Consul
+		_, _ = aclClient.TokenDelete(token.AccessorID, &consulApi.WriteOptions{})
+		return nil, err
+	}
+	m.useToken(token.SecretID)
+	return token, nil
+}
+
+// Reloader returns the runnable which reloads the operator token from secret on each replica, so replicas which are not
+// leaders use the token rotated by the leader
+func (m *OperatorTokenManager) Reloader() manager.Runnable {
+	return &operatorTokenReloader{manager: m}
+}
+
+type operatorTokenReloader struct {
+	manager *OperatorTokenManager
+}
+
+func (r *operatorTokenReloader) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(operatorTokenReloadPeriod):
+		}
+		secret, err := r.manager.readSecret(ctx)
+		if err != nil {
+			log.Error(err, "Can not reload operator token")
+			continue
+		}
+		if secret == nil {
+			continue
+		}
+		if token := string(secret.Data[operatorTokenSecretKey]); token != "" && token != getConsulToken() {
+			r.manager.useToken(token)
+			log.Info(fmt.Sprintf("Operator token is reloaded from secret [%s]", r.manager.SecretName))
+		}
+	}
+}
+
+// NeedLeaderElection returns false, because the token is used by all replicas
+func (r *operatorTokenReloader) NeedLeaderElection() bool {
+	return false
+}
+
+func (m *OperatorTokenManager) useToken(token string) {
+	setConsulToken(token)
+	// drop the bootstrap token, it is not needed anymore
+	bootstrapToken = ""
+	_ = os.Unsetenv("CONSUL_ACL_BOOTSTRAP_TOKEN")
+}
+
+func (m *OperatorTokenManager) readSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := m.Client.Get(ctx, types.NamespacedName{Name: m.SecretName, Namespace: m.Namespace}, secret)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read secret [%s]: %w", m.SecretName, err)
+	}
+	return secret, nil
+}
+
+func (m *OperatorTokenManager) writeSecret(ctx context.Context, secret *corev1.Secret, token *consulApi.ACLToken, previousAccessorID string) error {
+	if secret == nil {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: m.SecretName, Namespace: m.Namespace},
+			Type:       corev1.SecretTypeOpaque,
+		}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[operatorTokenRotatedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	secret.Data = map[string][]byte{
+		operatorTokenSecretKey:      []byte(token.SecretID),
+		operatorAccessorIDSecretKey: []byte(token.AccessorID),
+	}
+	if previousAccessorID != "" {
+		secret.Data[operatorPreviousAccessorIDSecretKey] = []byte(previousAccessorID)
+	}
+	var err error
+	if secret.ResourceVersion == "" {
+		err = m.Client.Create(ctx, secret)
+	} else {
+		err = m.Client.Update(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("can not save operator token to secret [%s]: %w", m.SecretName, err)
+	}
+	return nil
+}
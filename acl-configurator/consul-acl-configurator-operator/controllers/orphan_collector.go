@@ -0,0 +1,316 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
+)
+
+const (
+	orphanModeReport = "report"
+	orphanModeDelete = "delete"
+
+	defaultOrphanCollectionPeriod = time.Hour
+	defaultOrphanGracePeriod      = 24 * time.Hour
+
+	orphanCollectorManager = "orphan-collector"
+)
+
+// OrphanCollector periodically finds Consul ACL entities created for ConsulACL resources or service accounts
+// which do not exist anymore, for example because the finalizer was removed manually. Entities are mapped back
+// to their owners by the owner marker in their descriptions, and orphans are reported or deleted after the grace period.
+// Entities without the marker, for example created by hand, are never collected.
+type OrphanCollector struct {
+	Reader client.Reader
+	// Mode is "report" to only log orphans or "delete" to delete them
+	Mode        string
+	Period      time.Duration
+	GracePeriod time.Duration
+	// WatchNamespaces restricts namespaces which entities are collected. Empty list allows all namespaces.
+	WatchNamespaces []string
+
+	// firstSeen keeps the time when an entity was found orphaned for the first time
+	firstSeen map[string]time.Time
+}
+
+// entityOwner is the ConsulACL resource or the service account which Consul ACL entity belongs to
+type entityOwner struct {
+	name      string
+	namespace string
+}
+
+func NewOrphanCollector(reader client.Reader, watchNamespaces []string) (*OrphanCollector, error) {
+	collector := &OrphanCollector{
+		Reader:          reader,
+		Mode:            os.Getenv("ORPHAN_GC_MODE"),
+		Period:          defaultOrphanCollectionPeriod,
+		GracePeriod:     defaultOrphanGracePeriod,
+		WatchNamespaces: watchNamespaces,
+		firstSeen:       map[string]time.Time{},
+	}
+	if collector.Mode == "" {
+		collector.Mode = orphanModeReport
+	}
+	if collector.Mode != orphanModeReport && collector.Mode != orphanModeDelete {
+		return nil, fmt.Errorf("ORPHAN_GC_MODE must be %s or %s, but [%s] is specified", orphanModeReport, orphanModeDelete, collector.Mode)
+	}
+	var err error
+	if value := os.Getenv("ORPHAN_GC_PERIOD"); value != "" {
+		if collector.Period, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("ORPHAN_GC_PERIOD is invalid: %w", err)
+		}
+	}
+	if value := os.Getenv("ORPHAN_GC_GRACE_PERIOD"); value != "" {
+		if collector.GracePeriod, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("ORPHAN_GC_GRACE_PERIOD is invalid: %w", err)
+		}
+	}
+	return collector, nil
+}
+
+// Start collects orphans each period until the context is closed
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if !CheckACLSystem().Ready {
+				continue
+			}
+			if err := c.collect(ctx); err != nil {
+				log.Error(err, "Can not collect orphaned Consul ACL entities")
+			}
+		}
+	}
+}
+
+func (c *OrphanCollector) collect(ctx context.Context) error {
+	globalNames, err := c.getGlobalEntityNames(ctx)
+	if err != nil {
+		return err
+	}
+	owners := map[entityOwner]bool{}
+	orphans := map[string]bool{}
+
+	policies, _, err := aclClient.PolicyList(&consulApi.QueryOptions{})
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		owner, ok := c.getEntityOwner(policy.Name, policy.Description, globalNames)
+		if !ok {
+			continue
+		}
+		alive, err := c.isOwnerAlive(ctx, owner, owners)
+		if err != nil {
+			return err
+		}
+		if !alive {
+			key := auditEntityPolicy + "/" + policy.Name
+			orphans[key] = true
+			c.handleOrphan(key, owner, func(source *auditSource) error { return deletePolicy(policy.Name, source) })
+		}
+	}
+
+	roles, _, err := aclClient.RoleList(&consulApi.QueryOptions{})
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		owner, ok := c.getEntityOwner(role.Name, role.Description, globalNames)
+		if !ok {
+			continue
+		}
+		alive, err := c.isOwnerAlive(ctx, owner, owners)
+		if err != nil {
+			return err
+		}
+		if !alive {
+			key := auditEntityRole + "/" + role.Name
+			orphans[key] = true
+			c.handleOrphan(key, owner, func(source *auditSource) error { return deleteRole(role.Name, source) })
+		}
+	}
+
+	bindingRules, _, err := aclClient.BindingRuleList(authMethod, &consulApi.QueryOptions{})
+	if err != nil {
+		return err
+	}
+	for _, bindingRule := range bindingRules {
+		owner, orphaned, err := c.checkBindingRule(ctx, bindingRule, owners)
+		if err != nil {
+			return err
+		}
+		if orphaned {
+			rule := bindingRule
+			key := auditEntityBindingRule + "/" + rule.ID
+			orphans[key] = true
+			c.handleOrphan(key, owner, func(source *auditSource) error {
+				return deleteBindingRulesByBindName(*rule, []*consulApi.ACLBindingRule{rule}, source)
+			})
+		}
+	}
+
+	// entities which are not orphaned anymore start the grace period again if they become orphaned
+	for key := range c.firstSeen {
+		if !orphans[key] {
+			delete(c.firstSeen, key)
+		}
+	}
+	return nil
+}
+
+// handleOrphan reports the orphaned entity and deletes it if the grace period is over and deletion is enabled
+func (c *OrphanCollector) handleOrphan(key string, owner entityOwner, deleteFunc func(source *auditSource) error) {
+	firstSeen, ok := c.firstSeen[key]
+	if !ok {
+		firstSeen = time.Now()
+		c.firstSeen[key] = firstSeen
+	}
+	if c.Mode != orphanModeDelete || time.Since(firstSeen) < c.GracePeriod {
+		log.Info(fmt.Sprintf("Consul ACL entity %s is orphaned, owner %s/%s does not exist since %s",
+			key, owner.namespace, owner.name, firstSeen.Format(time.RFC3339)))
+		return
+	}
+	source := &auditSource{Name: owner.name, Namespace: owner.namespace, FieldManager: orphanCollectorManager}
+	if err := deleteFunc(source); err != nil {
+		log.Error(err, fmt.Sprintf("Can not delete orphaned Consul ACL entity %s", key))
+		return
+	}
+	log.Info(fmt.Sprintf("Orphaned Consul ACL entity %s of %s/%s is deleted", key, owner.namespace, owner.name))
+	delete(c.firstSeen, key)
+}
+
+// getEntityOwner maps policy or role back to the owner by the owner marker in its description.
+// Global entities are not owned.
+func (c *OrphanCollector) getEntityOwner(name string, description string, globalNames map[string]bool) (entityOwner, bool) {
+	if globalNames[name] {
+		return entityOwner{}, false
+	}
+	owner, ok := parseOwnerMarker(description)
+	if !ok || (len(c.WatchNamespaces) > 0 && !util.Contains(owner.namespace, c.WatchNamespaces)) {
+		return entityOwner{}, false
+	}
+	return owner, true
+}
+
+// checkBindingRule returns whether the binding rule is orphaned. Binding rules of service accounts are orphaned
+// if the service account does not exist, other binding rules are orphaned if the owner from their marker does not exist.
+func (c *OrphanCollector) checkBindingRule(ctx context.Context, bindingRule *consulApi.ACLBindingRule,
+	owners map[entityOwner]bool) (entityOwner, bool, error) {
+	if owner, ok := parseServiceAccountRuleDescription(bindingRule.Description); ok {
+		if len(c.WatchNamespaces) > 0 && !util.Contains(owner.namespace, c.WatchNamespaces) {
+			return entityOwner{}, false, nil
+		}
+		alive, err := c.isServiceAccountManaged(ctx, owner)
+		return owner, !alive, err
+	}
+	owner, ok := c.getEntityOwner(bindingRule.BindName, bindingRule.Description, nil)
+	if !ok {
+		return entityOwner{}, false, nil
+	}
+	alive, err := c.isOwnerAlive(ctx, owner, owners)
+	return owner, !alive, err
+}
+
+// parseServiceAccountRuleDescription returns the service account which manages the binding rule
+func parseServiceAccountRuleDescription(description string) (entityOwner, bool) {
+	prefix := strings.SplitN(serviceAccountRuleDescription, "%", 2)[0]
+	if !strings.HasPrefix(description, prefix) {
+		return entityOwner{}, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(description, prefix), "/", 2)
+	if len(parts) != 2 {
+		return entityOwner{}, false
+	}
+	return entityOwner{name: parts[1], namespace: parts[0]}, true
+}
+
+// isOwnerAlive checks that ConsulACL resource or managed service account with the name of owner exists
+func (c *OrphanCollector) isOwnerAlive(ctx context.Context, owner entityOwner, owners map[entityOwner]bool) (bool, error) {
+	if alive, ok := owners[owner]; ok {
+		return alive, nil
+	}
+	err := c.Reader.Get(ctx, types.NamespacedName{Name: owner.name, Namespace: owner.namespace}, &consulaclv1.ConsulACL{})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	alive := err == nil
+	if !alive {
+		if alive, err = c.isServiceAccountManaged(ctx, owner); err != nil {
+			return false, err
+		}
+	}
+	if !alive {
+		// the policy of token of ConsulExternalService is named after the custom resource too
+		err = c.Reader.Get(ctx, types.NamespacedName{Name: owner.name, Namespace: owner.namespace}, &consulacl.ConsulExternalService{})
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		alive = err == nil
+	}
+	owners[owner] = alive
+	return alive, nil
+}
+
+func (c *OrphanCollector) isServiceAccountManaged(ctx context.Context, owner entityOwner) (bool, error) {
+	serviceAccount := &corev1.ServiceAccount{}
+	err := c.Reader.Get(ctx, types.NamespacedName{Name: owner.name, Namespace: owner.namespace}, serviceAccount)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return isServiceAccountManaged(serviceAccount) || util.Contains(consulAclFinalizer, serviceAccount.Finalizers), nil
+}
+
+// getGlobalEntityNames returns names of policies and roles managed by ClusterConsulACL resources
+func (c *OrphanCollector) getGlobalEntityNames(ctx context.Context) (map[string]bool, error) {
+	clusterACLList := &consulacl.ClusterConsulACLList{}
+	if err := c.Reader.List(ctx, clusterACLList); err != nil {
+		return nil, err
+	}
+	globalNames := map[string]bool{}
+	for i := range clusterACLList.Items {
+		aclConfig, err := getClusterAclConfig(&clusterACLList.Items[i])
+		if err != nil {
+			continue
+		}
+		for _, policy := range aclConfig.Policies {
+			globalNames[globalEntityNamer.name(policy.Name)] = true
+		}
+		for _, role := range aclConfig.Roles {
+			globalNames[globalEntityNamer.name(role.Name)] = true
+		}
+	}
+	return globalNames, nil
+}
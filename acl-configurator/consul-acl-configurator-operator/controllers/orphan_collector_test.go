@@ -0,0 +1,148 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+func TestGetEntityOwner(t *testing.T) {
+	description := namespacedEntityNamer("acl", "team").markDescription("Reads keys.")
+	tests := []struct {
+		name            string
+		entityName      string
+		description     string
+		watchNamespaces []string
+		wantOwner       entityOwner
+		wantOk          bool
+	}{
+		{name: "marked entity", entityName: "acl_team_read", description: description, wantOwner: entityOwner{name: "acl", namespace: "team"}, wantOk: true},
+		{
+			name:       "marked entity in watched namespace",
+			entityName: "acl_team_read", description: description, watchNamespaces: []string{"other", "team"},
+			wantOwner: entityOwner{name: "acl", namespace: "team"}, wantOk: true,
+		},
+		{name: "marked entity in not watched namespace", entityName: "acl_team_read", description: description, watchNamespaces: []string{"other"}},
+		{name: "global entity", entityName: "global-read", description: description},
+		{name: "entity with owner-like name created by hand", entityName: "acl_team_read", description: "Reads keys."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collector := &OrphanCollector{WatchNamespaces: test.watchNamespaces}
+			owner, ok := collector.getEntityOwner(test.entityName, test.description, map[string]bool{"global-read": true})
+			if ok != test.wantOk || owner != test.wantOwner {
+				t.Errorf("getEntityOwner returned %+v, %t, want %+v, %t", owner, ok, test.wantOwner, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestParseServiceAccountRuleDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		wantOwner   entityOwner
+		wantOk      bool
+	}{
+		{
+			name:        "binding rule of service account",
+			description: fmt.Sprintf(serviceAccountRuleDescription, "team", "app"),
+			wantOwner:   entityOwner{name: "app", namespace: "team"},
+			wantOk:      true,
+		},
+		{name: "binding rule of ConsulACL", description: namespacedEntityNamer("acl", "team").markDescription("")},
+		{name: "binding rule created by hand", description: "Binds app"},
+		{name: "description without namespace", description: "Managed by service account team"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owner, ok := parseServiceAccountRuleDescription(test.description)
+			if ok != test.wantOk || owner != test.wantOwner {
+				t.Errorf("parseServiceAccountRuleDescription(%q) returned %+v, %t, want %+v, %t",
+					test.description, owner, ok, test.wantOwner, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestCheckBindingRule(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, consulaclv1.AddToScheme, consulacl.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("can not build scheme: %v", err)
+		}
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&consulaclv1.ConsulACL{ObjectMeta: metav1.ObjectMeta{Name: "acl", Namespace: "team"}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team",
+			Annotations: map[string]string{serviceAccountRolesAnnotation: "acl/reader"}}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "team"}},
+	).Build()
+	tests := []struct {
+		name         string
+		description  string
+		wantOwner    entityOwner
+		wantOrphaned bool
+	}{
+		{
+			name:        "binding rule of existing ConsulACL",
+			description: namespacedEntityNamer("acl", "team").markDescription(""),
+			wantOwner:   entityOwner{name: "acl", namespace: "team"},
+		},
+		{
+			name:         "binding rule of deleted ConsulACL",
+			description:  namespacedEntityNamer("deleted", "team").markDescription(""),
+			wantOwner:    entityOwner{name: "deleted", namespace: "team"},
+			wantOrphaned: true,
+		},
+		{
+			name:        "binding rule of managed service account",
+			description: fmt.Sprintf(serviceAccountRuleDescription, "team", "app"),
+			wantOwner:   entityOwner{name: "app", namespace: "team"},
+		},
+		{
+			name:         "binding rule of service account which is not managed anymore",
+			description:  fmt.Sprintf(serviceAccountRuleDescription, "team", "unmanaged"),
+			wantOwner:    entityOwner{name: "unmanaged", namespace: "team"},
+			wantOrphaned: true,
+		},
+		{name: "binding rule created by hand", description: "Binds app"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collector := &OrphanCollector{Reader: reader}
+			bindingRule := &consulApi.ACLBindingRule{BindName: "acl_team_reader", Description: test.description}
+			owner, orphaned, err := collector.checkBindingRule(context.Background(), bindingRule, map[entityOwner]bool{})
+			if err != nil {
+				t.Fatalf("checkBindingRule failed: %v", err)
+			}
+			if owner != test.wantOwner || orphaned != test.wantOrphaned {
+				t.Errorf("checkBindingRule returned %+v, %t, want %+v, %t", owner, orphaned, test.wantOwner, test.wantOrphaned)
+			}
+		})
+	}
+}
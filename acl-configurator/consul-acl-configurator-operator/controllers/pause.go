@@ -0,0 +1,118 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	conditionPaused        = "Paused"
+	conditionDriftDetected = "DriftDetected"
+	reasonPaused           = "ReconciliationPaused"
+	reasonNotPaused        = "ReconciliationActive"
+	reasonDrifted          = "EntitiesDiffer"
+	reasonInSync           = "EntitiesInSync"
+)
+
+var pausedAnnotation = consulacl.GroupVersion.Group + "/paused"
+
+func isPaused(cr *consulaclv1.ConsulACL) bool {
+	return cr.GetAnnotations()[pausedAnnotation] == "true"
+}
+
+func newPausedCondition(paused bool, generation int64) metav1.Condition {
+	if paused {
+		return metav1.Condition{
+			Type:               conditionPaused,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             reasonPaused,
+			Message:            fmt.Sprintf("Changes are not written to Consul until the %s annotation is removed", pausedAnnotation),
+		}
+	}
+	return metav1.Condition{
+		Type:               conditionPaused,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             reasonNotPaused,
+		Message:            "Changes are written to Consul",
+	}
+}
+
+func newDriftCondition(drifts []string, generation int64) metav1.Condition {
+	if len(drifts) == 0 {
+		return metav1.Condition{
+			Type:               conditionDriftDetected,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             reasonInSync,
+			Message:            "Consul ACL entities match the ACL configuration",
+		}
+	}
+	return metav1.Condition{
+		Type:               conditionDriftDetected,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             reasonDrifted,
+		Message:            strings.Join(drifts, "; "),
+	}
+}
+
+// detectDrift compares ACL configuration with Consul ACL entities without changing them the same way as inspection does.
+// Frozen entities are checked only for presence.
+func detectDrift(aclConfig *ACLConfig, customResourceName string, customResourceNamespace string) ([]string, error) {
+	states, err := inspectNamespacedACLConfig(aclClient, authMethod, aclConfig, customResourceName, customResourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []string
+	for _, state := range states {
+		if drift := formatDrift(state); drift != "" {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+// formatDrift returns the description of entity which is absent or differs from ACL configuration
+func formatDrift(state EntityState) string {
+	switch {
+	case state.State == EntityAbsent && state.Type == auditEntityBindingRule:
+		return fmt.Sprintf("binding rule for [%s] with selector [%s] is absent", state.ConsulName, state.Selector)
+	case state.State == EntityAbsent:
+		return fmt.Sprintf("%s [%s] is absent", state.Type, state.ConsulName)
+	case state.State == EntityDrifted:
+		return fmt.Sprintf("%s [%s] differs: %s", state.Type, state.ConsulName, strings.Join(state.Diff, ", "))
+	default:
+		return ""
+	}
+}
+
+func containsBindingRule(bindingRules []*consulApi.ACLBindingRule, bindingRule consulApi.ACLBindingRule) bool {
+	for _, existedRule := range bindingRules {
+		if existedRule.BindName == bindingRule.BindName && existedRule.BindType == bindingRule.BindType &&
+			existedRule.Selector == bindingRule.Selector {
+			return true
+		}
+	}
+	return false
+}
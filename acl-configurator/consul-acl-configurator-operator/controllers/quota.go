@@ -0,0 +1,198 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	conditionWithinQuota = "WithinQuota"
+	reasonQuotaSatisfied = "QuotaSatisfied"
+	reasonQuotaExceeded  = "QuotaExceeded"
+)
+
+var (
+	policiesQuotaAnnotation     = consulacl.GroupVersion.Group + "/quota-policies"
+	rolesQuotaAnnotation        = consulacl.GroupVersion.Group + "/quota-roles"
+	bindingRulesQuotaAnnotation = consulacl.GroupVersion.Group + "/quota-binding-rules"
+	tokensQuotaAnnotation       = consulacl.GroupVersion.Group + "/quota-tokens"
+)
+
+// default quotas of namespaces, zero means unlimited
+var (
+	defaultPoliciesQuota     = getIntEnv("QUOTA_POLICIES", 0)
+	defaultRolesQuota        = getIntEnv("QUOTA_ROLES", 0)
+	defaultBindingRulesQuota = getIntEnv("QUOTA_BINDING_RULES", 0)
+	defaultTokensQuota       = getIntEnv("QUOTA_TOKENS", 0)
+)
+
+// getQuotaUsage counts Consul ACL entities of the namespace of custom resource together with entities of its ACL configuration.
+// If olderOnly is true, only ConsulACL resources created before the custom resource are counted, so a new resource
+// can not exceed quotas for resources which are already applied.
+func getQuotaUsage(ctx context.Context, reader client.Reader, cr *consulaclv1.ConsulACL, aclConfig *ACLConfig, olderOnly bool) (*consulaclv1.QuotaUsage, error) {
+	usage, err := getNamespaceQuotaUsage(ctx, reader, cr.Namespace, func(other *consulaclv1.ConsulACL) bool {
+		return other.Name == cr.Name || (olderOnly && !isCreatedBefore(other, cr))
+	})
+	if err != nil {
+		return nil, err
+	}
+	addQuotaUsage(usage, aclConfig)
+	return usage, nil
+}
+
+// getNamespaceQuotaUsage counts Consul ACL entities of all sources of the namespace: ConsulACL resources which are not skipped,
+// managed service accounts and ConsulExternalService resources
+func getNamespaceQuotaUsage(ctx context.Context, reader client.Reader, namespaceName string,
+	skip func(*consulaclv1.ConsulACL) bool) (*consulaclv1.QuotaUsage, error) {
+	namespace := &corev1.Namespace{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace); err != nil {
+		return nil, err
+	}
+	usage := &consulaclv1.QuotaUsage{
+		Policies:     consulaclv1.EntityUsage{Limit: getNamespaceQuota(namespace, policiesQuotaAnnotation, defaultPoliciesQuota)},
+		Roles:        consulaclv1.EntityUsage{Limit: getNamespaceQuota(namespace, rolesQuotaAnnotation, defaultRolesQuota)},
+		BindingRules: consulaclv1.EntityUsage{Limit: getNamespaceQuota(namespace, bindingRulesQuotaAnnotation, defaultBindingRulesQuota)},
+		Tokens:       consulaclv1.EntityUsage{Limit: getNamespaceQuota(namespace, tokensQuotaAnnotation, defaultTokensQuota)},
+	}
+
+	consulACLs := &consulaclv1.ConsulACLList{}
+	if err := reader.List(ctx, consulACLs, client.InNamespace(namespaceName)); err != nil {
+		return nil, err
+	}
+	for i := range consulACLs.Items {
+		other := &consulACLs.Items[i]
+		if !other.DeletionTimestamp.IsZero() || skip(other) {
+			continue
+		}
+		otherConfig, err := getAclConfig(other)
+		if err != nil {
+			// invalid configuration is not applied, so it does not use quotas
+			continue
+		}
+		addQuotaUsage(usage, otherConfig)
+	}
+
+	serviceAccounts := &corev1.ServiceAccountList{}
+	if err := reader.List(ctx, serviceAccounts, client.InNamespace(namespaceName)); err != nil {
+		return nil, err
+	}
+	for i := range serviceAccounts.Items {
+		if serviceAccount := &serviceAccounts.Items[i]; serviceAccount.DeletionTimestamp.IsZero() && isServiceAccountManaged(serviceAccount) {
+			addServiceAccountQuotaUsage(usage, serviceAccount)
+		}
+	}
+
+	externalServices := &consulacl.ConsulExternalServiceList{}
+	if err := reader.List(ctx, externalServices, client.InNamespace(namespaceName)); err != nil {
+		return nil, err
+	}
+	for _, externalService := range externalServices.Items {
+		if externalService.DeletionTimestamp.IsZero() {
+			// the token of ConsulExternalService and its policy
+			usage.Policies.Used++
+			usage.Tokens.Used++
+		}
+	}
+	return usage, nil
+}
+
+func addQuotaUsage(usage *consulaclv1.QuotaUsage, aclConfig *ACLConfig) {
+	usage.Policies.Used += len(aclConfig.Policies)
+	usage.Roles.Used += len(aclConfig.Roles)
+	usage.BindingRules.Used += len(aclConfig.BindRules)
+}
+
+// addServiceAccountQuotaUsage counts a binding rule for each role of the service account, and the policy, the role
+// and the binding rule for its inline rules
+func addServiceAccountQuotaUsage(usage *consulaclv1.QuotaUsage, serviceAccount *corev1.ServiceAccount) {
+	// invalid role references are not applied, so they do not use quotas
+	roleRefs, _ := parseServiceAccountRoles(serviceAccount.Annotations[serviceAccountRolesAnnotation])
+	usage.BindingRules.Used += len(roleRefs)
+	if strings.TrimSpace(serviceAccount.Annotations[serviceAccountRulesAnnotation]) != "" {
+		usage.Policies.Used++
+		usage.Roles.Used++
+		usage.BindingRules.Used++
+	}
+}
+
+func isCreatedBefore(cr *consulaclv1.ConsulACL, other *consulaclv1.ConsulACL) bool {
+	if cr.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return cr.Name < other.Name
+	}
+	return cr.CreationTimestamp.Before(&other.CreationTimestamp)
+}
+
+// getNamespaceQuota returns the quota from the annotation of namespace or the default quota
+func getNamespaceQuota(namespace *corev1.Namespace, annotation string, defaultQuota int) int {
+	value, ok := namespace.Annotations[annotation]
+	if !ok {
+		return defaultQuota
+	}
+	quota, err := strconv.Atoi(value)
+	if err != nil || quota < 0 {
+		log.Error(err, fmt.Sprintf("Quota [%s] of namespace [%s] is invalid, default value %d is used", value, namespace.Name, defaultQuota))
+		return defaultQuota
+	}
+	return quota
+}
+
+// findExceededQuotas returns descriptions of quotas which are exceeded
+func findExceededQuotas(usage *consulaclv1.QuotaUsage) []string {
+	var exceeded []string
+	for _, entity := range []struct {
+		name  string
+		usage consulaclv1.EntityUsage
+	}{
+		{"policies", usage.Policies},
+		{"roles", usage.Roles},
+		{"binding rules", usage.BindingRules},
+		{"tokens", usage.Tokens},
+	} {
+		if entity.usage.Limit > 0 && entity.usage.Used > entity.usage.Limit {
+			exceeded = append(exceeded, fmt.Sprintf("%s: %d of %d", entity.name, entity.usage.Used, entity.usage.Limit))
+		}
+	}
+	return exceeded
+}
+
+func newQuotaCondition(exceeded []string, generation int64) metav1.Condition {
+	if len(exceeded) == 0 {
+		return metav1.Condition{
+			Type:               conditionWithinQuota,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             reasonQuotaSatisfied,
+			Message:            "Consul ACL entities of the namespace are within quotas",
+		}
+	}
+	return metav1.Condition{
+		Type:               conditionWithinQuota,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             reasonQuotaExceeded,
+		Message:            fmt.Sprintf("ACL configuration is not applied, quotas of the namespace are exceeded: %s", strings.Join(exceeded, ", ")),
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+func TestGetNamespaceQuotaUsage(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team", Annotations: map[string]string{
+		policiesQuotaAnnotation: "10",
+		tokensQuotaAnnotation:   "2",
+	}}}
+	consulACL := func(name string, policies int, roles int, bindRules int) client.Object {
+		cr := &consulaclv1.ConsulACL{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"}}
+		for i := 0; i < policies; i++ {
+			cr.Spec.Policies = append(cr.Spec.Policies, consulaclv1.Policy{Name: "policy"})
+		}
+		for i := 0; i < roles; i++ {
+			cr.Spec.Roles = append(cr.Spec.Roles, consulaclv1.Role{Name: "role"})
+		}
+		for i := 0; i < bindRules; i++ {
+			cr.Spec.BindRules = append(cr.Spec.BindRules, consulaclv1.BindingRule{BindName: "role"})
+		}
+		return cr
+	}
+	serviceAccount := func(name string, annotations map[string]string) client.Object {
+		return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team", Annotations: annotations}}
+	}
+	externalService := func(name string, namespace string) client.Object {
+		return &consulacl.ConsulExternalService{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	tests := []struct {
+		name    string
+		objects []client.Object
+		skip    string
+		want    consulaclv1.QuotaUsage
+	}{
+		{name: "empty namespace"},
+		{
+			name:    "ConsulACL resources",
+			objects: []client.Object{consulACL("first", 2, 1, 1), consulACL("second", 1, 0, 0)},
+			want: consulaclv1.QuotaUsage{
+				Policies:     consulaclv1.EntityUsage{Used: 3},
+				Roles:        consulaclv1.EntityUsage{Used: 1},
+				BindingRules: consulaclv1.EntityUsage{Used: 1},
+			},
+		},
+		{
+			name:    "skipped ConsulACL resource",
+			objects: []client.Object{consulACL("first", 2, 1, 1), consulACL("second", 1, 0, 0)},
+			skip:    "first",
+			want:    consulaclv1.QuotaUsage{Policies: consulaclv1.EntityUsage{Used: 1}},
+		},
+		{
+			name: "service accounts",
+			objects: []client.Object{
+				serviceAccount("roles", map[string]string{serviceAccountRolesAnnotation: "acl/reader, acl/writer"}),
+				serviceAccount("rules", map[string]string{serviceAccountRulesAnnotation: `key_prefix "" { policy = "read" }`}),
+				serviceAccount("invalid-roles", map[string]string{serviceAccountRolesAnnotation: "reader"}),
+				serviceAccount("unmanaged", nil),
+			},
+			want: consulaclv1.QuotaUsage{
+				Policies:     consulaclv1.EntityUsage{Used: 1},
+				Roles:        consulaclv1.EntityUsage{Used: 1},
+				BindingRules: consulaclv1.EntityUsage{Used: 3},
+			},
+		},
+		{
+			name:    "external services",
+			objects: []client.Object{externalService("first", "team"), externalService("second", "team"), externalService("other", "other")},
+			want: consulaclv1.QuotaUsage{
+				Policies: consulaclv1.EntityUsage{Used: 2},
+				Tokens:   consulaclv1.EntityUsage{Used: 2},
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, consulaclv1.AddToScheme, consulacl.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("can not build scheme: %v", err)
+		}
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).WithObjects(test.objects...).Build()
+			usage, err := getNamespaceQuotaUsage(context.Background(), reader, "team", func(cr *consulaclv1.ConsulACL) bool {
+				return cr.Name == test.skip
+			})
+			if err != nil {
+				t.Fatalf("getNamespaceQuotaUsage failed: %v", err)
+			}
+			test.want.Policies.Limit = 10
+			test.want.Tokens.Limit = 2
+			if !reflect.DeepEqual(*usage, test.want) {
+				t.Errorf("getNamespaceQuotaUsage returned %+v, want %+v", *usage, test.want)
+			}
+		})
+	}
+}
+
+func TestGetNamespaceQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        int
+	}{
+		{name: "no annotation", want: 5},
+		{name: "annotation", annotations: map[string]string{rolesQuotaAnnotation: "7"}, want: 7},
+		{name: "unlimited", annotations: map[string]string{rolesQuotaAnnotation: "0"}, want: 0},
+		{name: "annotation of other quota", annotations: map[string]string{policiesQuotaAnnotation: "7"}, want: 5},
+		{name: "negative", annotations: map[string]string{rolesQuotaAnnotation: "-1"}, want: 5},
+		{name: "not a number", annotations: map[string]string{rolesQuotaAnnotation: "many"}, want: 5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team", Annotations: test.annotations}}
+			if got := getNamespaceQuota(namespace, rolesQuotaAnnotation, 5); got != test.want {
+				t.Errorf("getNamespaceQuota returned %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindExceededQuotas(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage consulaclv1.QuotaUsage
+		want  []string
+	}{
+		{name: "unlimited", usage: consulaclv1.QuotaUsage{Policies: consulaclv1.EntityUsage{Used: 100}}},
+		{name: "quota is reached", usage: consulaclv1.QuotaUsage{Roles: consulaclv1.EntityUsage{Used: 2, Limit: 2}}},
+		{
+			name: "quotas are exceeded",
+			usage: consulaclv1.QuotaUsage{
+				Policies:     consulaclv1.EntityUsage{Used: 3, Limit: 2},
+				BindingRules: consulaclv1.EntityUsage{Used: 1, Limit: 2},
+				Tokens:       consulaclv1.EntityUsage{Used: 2, Limit: 1},
+			},
+			want: []string{"policies: 3 of 2", "tokens: 2 of 1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := findExceededQuotas(&test.usage); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("findExceededQuotas returned %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"net"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strings"
+	"time"
+
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
+)
+
+const (
+	// serviceAccountEntityName is the name of policy and role which are created for inline rules of service account
+	serviceAccountEntityName = "serviceaccount"
+	// serviceAccountRuleDescription marks binding rules managed by service accounts, so they are not mixed
+	// with binding rules of ConsulACL resources for the same service account
+	serviceAccountRuleDescription = "Managed by service account %s/%s"
+)
+
+var (
+	serviceAccountRolesAnnotation  = consulacl.GroupVersion.Group + "/consul-roles"
+	serviceAccountRulesAnnotation  = consulacl.GroupVersion.Group + "/consul-rules"
+	serviceAccountStatusAnnotation = consulacl.GroupVersion.Group + "/consul-acl-status"
+)
+
+// ServiceAccountReconciler maintains binding rules, policies and roles for annotated service accounts
+type ServiceAccountReconciler struct {
+	Client client.Client
+}
+
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;update;patch
+
+func (r *ServiceAccountReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	serviceAccount := &corev1.ServiceAccount{}
+	err := r.Client.Get(ctx, request.NamespacedName, serviceAccount)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !serviceAccount.DeletionTimestamp.IsZero() || !isServiceAccountManaged(serviceAccount) {
+		if !controllerutil.ContainsFinalizer(serviceAccount, consulAclFinalizer) {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Deleting Consul ACL entities of service account")
+		if err = deleteServiceAccountEntities(serviceAccount); err != nil {
+			log.Error(err, "Can not delete Consul ACL entities of service account")
+			return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, nil
+		}
+		patch := client.MergeFrom(serviceAccount.DeepCopy())
+		controllerutil.RemoveFinalizer(serviceAccount, consulAclFinalizer)
+		delete(serviceAccount.Annotations, serviceAccountStatusAnnotation)
+		return reconcile.Result{}, r.Client.Patch(ctx, serviceAccount, patch)
+	}
+
+	if !controllerutil.ContainsFinalizer(serviceAccount, consulAclFinalizer) {
+		patch := client.MergeFrom(serviceAccount.DeepCopy())
+		controllerutil.AddFinalizer(serviceAccount, consulAclFinalizer)
+		if err = r.Client.Patch(ctx, serviceAccount, patch); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	reqLogger.Info("Reconciling Consul ACL entities of service account")
+	aclSystem := CheckACLSystem()
+	if !aclSystem.Ready {
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, r.setStatus(ctx, serviceAccount,
+			fmt.Sprintf("error: Consul ACL system is not ready: %s", aclSystem.Message))
+	}
+	status, err := r.applyServiceAccountEntities(ctx, serviceAccount)
+	if err != nil {
+		if _, ok := err.(net.Error); ok {
+			log.Error(err, "Error during connection to Consul")
+		} else {
+			log.Error(err, "Can not apply Consul ACL entities of service account")
+		}
+		return reconcile.Result{RequeueAfter: time.Second * time.Duration(periodTime)}, r.setStatus(ctx, serviceAccount,
+			fmt.Sprintf("error: %s", err))
+	}
+	return reconcile.Result{}, r.setStatus(ctx, serviceAccount, status)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ServiceAccountReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	annotationPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isServiceAccountManaged(e.Object) || controllerutil.ContainsFinalizer(e.Object, consulAclFinalizer)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Ignore updates of the status annotation which is written by the operator
+			oldAnnotations := e.ObjectOld.GetAnnotations()
+			newAnnotations := e.ObjectNew.GetAnnotations()
+			return oldAnnotations[serviceAccountRolesAnnotation] != newAnnotations[serviceAccountRolesAnnotation] ||
+				oldAnnotations[serviceAccountRulesAnnotation] != newAnnotations[serviceAccountRulesAnnotation] ||
+				e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("serviceaccount").
+		For(&corev1.ServiceAccount{}, builder.WithPredicates(annotationPredicate)).
+		Complete(r)
+}
+
+func isServiceAccountManaged(serviceAccount client.Object) bool {
+	annotations := serviceAccount.GetAnnotations()
+	return strings.TrimSpace(annotations[serviceAccountRolesAnnotation]) != "" ||
+		strings.TrimSpace(annotations[serviceAccountRulesAnnotation]) != ""
+}
+
+// applyServiceAccountEntities creates the policy and the role for inline rules and binding rules for all roles
+// of service account. Binding rules which are not required anymore are deleted.
+func (r *ServiceAccountReconciler) applyServiceAccountEntities(ctx context.Context, serviceAccount *corev1.ServiceAccount) (string, error) {
+	source := newAuditSource(serviceAccount)
+	namer := namespacedEntityNamer(serviceAccount.Name, serviceAccount.Namespace)
+	statusMap := StatusHolder{}
+	var desiredRules []consulApi.ACLBindingRule
+
+	roleRefs, err := parseServiceAccountRoles(serviceAccount.Annotations[serviceAccountRolesAnnotation])
+	if err != nil {
+		return "", err
+	}
+	for _, roleRef := range roleRefs {
+		desiredRules = append(desiredRules, newServiceAccountBindingRule(serviceAccount, roleRef[1], roleRef[0]))
+	}
+
+	rules := strings.TrimSpace(serviceAccount.Annotations[serviceAccountRulesAnnotation])
+	if rules != "" {
+		desiredRules = append(desiredRules, newServiceAccountBindingRule(serviceAccount, serviceAccountEntityName, serviceAccount.Name))
+	}
+	existedBindingRules, err := listServiceAccountBindingRules(serviceAccount)
+	if err != nil {
+		return "", err
+	}
+	if err = r.checkServiceAccountQuotas(ctx, serviceAccount.Namespace, desiredRules, existedBindingRules); err != nil {
+		return "", err
+	}
+
+	inlineConfig := newServiceAccountACLConfig(rules)
+	if rules == "" {
+		if err = deleteRoles(inlineConfig, namer, source); err != nil {
+			return "", err
+		}
+		if err = deletePolicies(inlineConfig, namer, source); err != nil {
+			return "", err
+		}
+	} else {
+		if reason, forbidden := r.checkInlineRules(ctx, serviceAccount.Namespace, inlineConfig.Policies); forbidden {
+			return "", fmt.Errorf("inline rules are not applied: %s", reason)
+		}
+		policiesStatus, processedPolicies, err := processPolicies(inlineConfig.Policies, namer, source)
+		if err != nil {
+			return "", err
+		}
+		rolesStatus, err := processRoles(inlineConfig.Roles, processedPolicies, namer, source)
+		if err != nil {
+			return "", err
+		}
+		for key, value := range *policiesStatus {
+			statusMap["policy "+key] = value
+		}
+		for key, value := range *rolesStatus {
+			statusMap["role "+key] = value
+		}
+	}
+
+	for _, existedRule := range existedBindingRules {
+		if !containsBindingRule(bindingRulePointers(desiredRules), *existedRule) {
+			if err = deleteBindingRulesByBindName(*existedRule, []*consulApi.ACLBindingRule{existedRule}, source); err != nil {
+				return "", err
+			}
+		}
+	}
+	for _, desiredRule := range desiredRules {
+		if containsBindingRule(existedBindingRules, desiredRule) {
+			statusMap["binding rule "+desiredRule.BindName] = "exists"
+			continue
+		}
+		action, err := applyBindRule(desiredRule, source)
+		if err != nil {
+			return "", err
+		}
+		statusMap["binding rule "+desiredRule.BindName] = fmt.Sprintf("%sd", action)
+	}
+	return statusMap.GetStatus(), nil
+}
+
+// checkServiceAccountQuotas checks quotas of the namespace before Consul ACL entities of service account are created.
+// The service account is counted by the namespace usage, so quotas are not checked if all its binding rules exist.
+func (r *ServiceAccountReconciler) checkServiceAccountQuotas(ctx context.Context, namespace string,
+	desiredRules []consulApi.ACLBindingRule, existedRules []*consulApi.ACLBindingRule) error {
+	created := false
+	for _, desiredRule := range desiredRules {
+		if !containsBindingRule(existedRules, desiredRule) {
+			created = true
+			break
+		}
+	}
+	if !created {
+		return nil
+	}
+	usage, err := getNamespaceQuotaUsage(ctx, r.Client, namespace, func(*consulaclv1.ConsulACL) bool { return false })
+	if err != nil {
+		return err
+	}
+	if exceeded := findExceededQuotas(usage); len(exceeded) > 0 {
+		return fmt.Errorf("Consul ACL entities are not applied, quotas of the namespace are exceeded: %s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// checkInlineRules checks inline rules against guardrails of the namespace. Privileged rules are not allowed
+// in annotations, because they can not be approved there.
+func (r *ServiceAccountReconciler) checkInlineRules(ctx context.Context, namespace string, policies []ACLPolicyAdapter) (string, bool) {
+	violations, err := findGuardrailViolations(ctx, r.Client, namespace, policies)
+	if err != nil {
+		return err.Error(), true
+	}
+	if reason, ok := violations[serviceAccountEntityName]; ok {
+		return reason, true
+	}
+	if rule, ok := findPrivilegedPolicies(policies)[serviceAccountEntityName]; ok {
+		return fmt.Sprintf("rule '%s' requires approval, use ConsulACL resource instead", rule), true
+	}
+	return "", false
+}
+
+func deleteServiceAccountEntities(serviceAccount *corev1.ServiceAccount) error {
+	source := newAuditSource(serviceAccount)
+	existedBindingRules, err := listServiceAccountBindingRules(serviceAccount)
+	if err != nil {
+		return err
+	}
+	for _, existedRule := range existedBindingRules {
+		if err = deleteBindingRulesByBindName(*existedRule, []*consulApi.ACLBindingRule{existedRule}, source); err != nil {
+			return err
+		}
+	}
+	namer := namespacedEntityNamer(serviceAccount.Name, serviceAccount.Namespace)
+	inlineConfig := newServiceAccountACLConfig("")
+	if err = deleteRoles(inlineConfig, namer, source); err != nil {
+		return err
+	}
+	return deletePolicies(inlineConfig, namer, source)
+}
+
+// parseServiceAccountRoles parses comma separated references to roles of ConsulACL resources from the namespace
+// of service account in the "<ConsulACL name>/<role name>" format
+func parseServiceAccountRoles(value string) ([][2]string, error) {
+	var roleRefs [][2]string
+	for _, roleRef := range strings.Split(value, ",") {
+		roleRef = strings.TrimSpace(roleRef)
+		if roleRef == "" {
+			continue
+		}
+		parts := strings.SplitN(roleRef, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("role reference [%s] in the %s annotation must be in the <ConsulACL name>/<role name> format",
+				roleRef, serviceAccountRolesAnnotation)
+		}
+		roleRefs = append(roleRefs, [2]string{parts[0], parts[1]})
+	}
+	sort.Slice(roleRefs, func(i, j int) bool {
+		return roleRefs[i][0]+"/"+roleRefs[i][1] < roleRefs[j][0]+"/"+roleRefs[j][1]
+	})
+	return roleRefs, nil
+}
+
+func newServiceAccountACLConfig(rules string) *ACLConfig {
+	policy := ACLPolicyAdapter{}
+	policy.Name = serviceAccountEntityName
+	policy.Rules = rules
+	return &ACLConfig{
+		Policies: []ACLPolicyAdapter{policy},
+		Roles: []ACLRoleAdapter{{
+			Name:        serviceAccountEntityName,
+			PolicyNames: []string{serviceAccountEntityName},
+		}},
+	}
+}
+
+// newServiceAccountBindingRule builds binding rule with the same naming and selector as binding rules of ConsulACL resources.
+// Binding rules of service account are found by their description, so it is not marked with the owner of role.
+func newServiceAccountBindingRule(serviceAccount *corev1.ServiceAccount, roleName string, customResourceName string) consulApi.ACLBindingRule {
+	bindingRule := convertBindRuleAdapterToBindRule(ACLBindingRuleAdapter{
+		ServiceAccountName: serviceAccount.Name,
+		BindName:           roleName,
+	}, customResourceName, serviceAccount.Namespace)
+	bindingRule.Description = fmt.Sprintf(serviceAccountRuleDescription, serviceAccount.Namespace, serviceAccount.Name)
+	return bindingRule
+}
+
+func listServiceAccountBindingRules(serviceAccount *corev1.ServiceAccount) ([]*consulApi.ACLBindingRule, error) {
+	bindingRules, _, err := aclClient.BindingRuleList(authMethod, &consulApi.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	description := fmt.Sprintf(serviceAccountRuleDescription, serviceAccount.Namespace, serviceAccount.Name)
+	var managedRules []*consulApi.ACLBindingRule
+	for _, bindingRule := range bindingRules {
+		if bindingRule.Description == description {
+			managedRules = append(managedRules, bindingRule)
+		}
+	}
+	return managedRules, nil
+}
+
+func bindingRulePointers(bindingRules []consulApi.ACLBindingRule) []*consulApi.ACLBindingRule {
+	var pointers []*consulApi.ACLBindingRule
+	for i := range bindingRules {
+		pointers = append(pointers, &bindingRules[i])
+	}
+	return pointers
+}
+
+// setStatus writes the result of processing to the status annotation of service account
+func (r *ServiceAccountReconciler) setStatus(ctx context.Context, serviceAccount *corev1.ServiceAccount, status string) error {
+	if serviceAccount.Annotations[serviceAccountStatusAnnotation] == status {
+		return nil
+	}
+	patch := client.MergeFrom(serviceAccount.DeepCopy())
+	if serviceAccount.Annotations == nil {
+		serviceAccount.Annotations = map[string]string{}
+	}
+	serviceAccount.Annotations[serviceAccountStatusAnnotation] = status
+	return r.Client.Patch(ctx, serviceAccount, patch)
+}
@@ -0,0 +1,197 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+
+	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/aclrules"
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
+)
+
+// AccessSimulation is the result of local evaluation of access of service account to Consul resource
+type AccessSimulation struct {
+	aclrules.Decision
+	// Roles are Consul names of roles bound to the service account
+	Roles []string `json:"roles"`
+	// Policies are Consul names of policies which rules are evaluated
+	Policies []string `json:"policies"`
+	// NotEvaluated describes bound entities which rules are not known without Consul, for example templated policies
+	NotEvaluated []string `json:"notEvaluated,omitempty"`
+}
+
+// simulatedRole is a role of ACL configuration bound to the service account
+type simulatedRole struct {
+	role  ACLRoleAdapter
+	namer entityNamer
+	// policies are policies of the same ACL configuration by their names
+	policies map[string]ACLPolicyAdapter
+}
+
+// SimulateServiceAccountAccess evaluates access of service account to the segment of Consul resource by rules
+// of ConsulACL and ClusterConsulACL resources and annotations of service account. Consul is not requested,
+// so entities which are not managed by the operator are not taken into account.
+func SimulateServiceAccountAccess(ctx context.Context, reader client.Reader, serviceAccount *corev1.ServiceAccount,
+	resource string, segment string, access string, defaultAllow bool) (*AccessSimulation, error) {
+	simulation := &AccessSimulation{}
+	roles, err := findServiceAccountRoles(ctx, reader, serviceAccount, simulation)
+	if err != nil {
+		return nil, err
+	}
+	var globalPolicies map[string]ACLPolicyAdapter
+	var rules []aclrules.PolicyRule
+	evaluatedPolicies := map[string]bool{}
+	addPolicy := func(policy ACLPolicyAdapter, consulName string) {
+		if evaluatedPolicies[consulName] {
+			return
+		}
+		evaluatedPolicies[consulName] = true
+		simulation.Policies = append(simulation.Policies, consulName)
+		policyRules, err := aclrules.Parse(policy.Rules)
+		if err != nil {
+			simulation.NotEvaluated = append(simulation.NotEvaluated, fmt.Sprintf("policy [%s]: %v", consulName, err))
+			return
+		}
+		for _, rule := range policyRules {
+			rules = append(rules, aclrules.PolicyRule{Rule: rule, Policy: consulName})
+		}
+	}
+	for _, role := range roles {
+		simulation.Roles = append(simulation.Roles, role.namer.name(role.role.Name))
+		for _, policyName := range role.role.PolicyNames {
+			policy, ok := role.policies[policyName]
+			if !ok {
+				simulation.NotEvaluated = append(simulation.NotEvaluated,
+					fmt.Sprintf("policy [%s] of role [%s] is not found", policyName, role.role.Name))
+				continue
+			}
+			addPolicy(policy, role.namer.name(policyName))
+		}
+		if len(role.role.GlobalPolicyNames) > 0 && globalPolicies == nil {
+			if globalPolicies, err = getGlobalPolicies(ctx, reader); err != nil {
+				return nil, err
+			}
+		}
+		for _, policyName := range role.role.GlobalPolicyNames {
+			policy, ok := globalPolicies[policyName]
+			if !ok {
+				simulation.NotEvaluated = append(simulation.NotEvaluated,
+					fmt.Sprintf("global policy [%s] of role [%s] is not managed by ClusterConsulACL", policyName, role.role.Name))
+				continue
+			}
+			addPolicy(policy, globalEntityNamer.name(policyName))
+		}
+		for _, templatedPolicy := range role.role.TemplatedPolicies {
+			simulation.NotEvaluated = append(simulation.NotEvaluated,
+				fmt.Sprintf("templated policy [%s] of role [%s]", templatedPolicy.TemplateName, role.role.Name))
+		}
+	}
+	simulation.Decision = aclrules.Authorize(rules, resource, segment, access, defaultAllow)
+	return simulation, nil
+}
+
+// findServiceAccountRoles returns roles bound to the service account by binding rules of ConsulACL resources
+// from its namespace and by annotations of service account
+func findServiceAccountRoles(ctx context.Context, reader client.Reader, serviceAccount *corev1.ServiceAccount,
+	simulation *AccessSimulation) ([]simulatedRole, error) {
+	instances := &consulaclv1.ConsulACLList{}
+	if err := reader.List(ctx, instances, client.InNamespace(serviceAccount.Namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(instances.Items, func(i, j int) bool {
+		return instances.Items[i].Name < instances.Items[j].Name
+	})
+	aclConfigs := map[string]*ACLConfig{}
+	for _, instance := range instances.Items {
+		if !instance.DeletionTimestamp.IsZero() {
+			continue
+		}
+		aclConfig, err := getAclConfig(&instance)
+		if err != nil {
+			simulation.NotEvaluated = append(simulation.NotEvaluated,
+				fmt.Sprintf("ConsulACL [%s] has invalid ACL configuration: %v", instance.Name, err))
+			continue
+		}
+		aclConfigs[instance.Name] = aclConfig
+	}
+
+	var roleRefs [][2]string
+	for _, instance := range instances.Items {
+		aclConfig, ok := aclConfigs[instance.Name]
+		if !ok {
+			continue
+		}
+		for _, bindRule := range aclConfig.BindRules {
+			if bindRule.ServiceAccountName != serviceAccount.Name || bindRule.BindName == "" {
+				continue
+			}
+			if bindRule.BindType == string(consulApi.BindingRuleBindTypeTemplatedPolicy) {
+				simulation.NotEvaluated = append(simulation.NotEvaluated,
+					fmt.Sprintf("templated policy [%s] of ConsulACL [%s]", bindRule.BindName, instance.Name))
+				continue
+			}
+			roleRefs = append(roleRefs, [2]string{instance.Name, bindRule.BindName})
+		}
+	}
+	annotatedRoleRefs, err := parseServiceAccountRoles(serviceAccount.Annotations[serviceAccountRolesAnnotation])
+	if err != nil {
+		simulation.NotEvaluated = append(simulation.NotEvaluated, err.Error())
+	}
+	roleRefs = append(roleRefs, annotatedRoleRefs...)
+
+	var roles []simulatedRole
+	for _, roleRef := range roleRefs {
+		aclConfig, ok := aclConfigs[roleRef[0]]
+		if !ok {
+			simulation.NotEvaluated = append(simulation.NotEvaluated,
+				fmt.Sprintf("role [%s] of ConsulACL [%s] is not found", roleRef[1], roleRef[0]))
+			continue
+		}
+		roles = appendSimulatedRole(roles, aclConfig, roleRef[1], namespacedEntityNamer(roleRef[0], serviceAccount.Namespace), simulation)
+	}
+	if rules := strings.TrimSpace(serviceAccount.Annotations[serviceAccountRulesAnnotation]); rules != "" {
+		roles = appendSimulatedRole(roles, newServiceAccountACLConfig(rules), serviceAccountEntityName,
+			namespacedEntityNamer(serviceAccount.Name, serviceAccount.Namespace), simulation)
+	}
+	return roles, nil
+}
+
+func appendSimulatedRole(roles []simulatedRole, aclConfig *ACLConfig, roleName string, namer entityNamer,
+	simulation *AccessSimulation) []simulatedRole {
+	for _, role := range roles {
+		if role.namer == namer && role.role.Name == roleName {
+			return roles
+		}
+	}
+	for _, role := range aclConfig.Roles {
+		if role.Name != roleName {
+			continue
+		}
+		policies := map[string]ACLPolicyAdapter{}
+		for _, policy := range aclConfig.Policies {
+			policies[policy.Name] = policy
+		}
+		return append(roles, simulatedRole{role: role, namer: namer, policies: policies})
+	}
+	simulation.NotEvaluated = append(simulation.NotEvaluated,
+		fmt.Sprintf("role [%s] is not found in ACL configuration", namer.fullName(roleName)))
+	return roles
+}
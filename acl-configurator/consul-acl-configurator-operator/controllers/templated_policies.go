@@ -0,0 +1,224 @@
+// Copyright 2024-2025 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	consulApi "github.com/hashicorp/consul/api"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// templated policies are built into Consul, so their list changes only with Consul upgrade
+const templatedPoliciesCacheTimeout = 5 * time.Minute
+
+var templatedPolicyNames map[string]bool
+var templatedPolicyNamesCheckedAt time.Time
+var templatedPolicyNamesMutex sync.Mutex
+
+// renderedTemplatedPolicies caches rules of templated policies by the templated policy and its variables
+var renderedTemplatedPolicies = map[string]string{}
+var renderedTemplatedPoliciesCheckedAt time.Time
+var renderedTemplatedPoliciesMutex sync.Mutex
+
+// checkTemplatedPolicies returns an error if one of template names is not supported by Consul server
+func checkTemplatedPolicies(templateNames ...string) error {
+	if len(templateNames) == 0 {
+		return nil
+	}
+	available, err := getTemplatedPolicyNames()
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for _, templateName := range templateNames {
+		if !available[templateName] {
+			unknown = append(unknown, templateName)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("templated policies %s are not supported by Consul, available ones are %s",
+			strings.Join(unknown, ", "), strings.Join(sortedKeys(available), ", "))
+	}
+	return nil
+}
+
+func getTemplatedPolicyNames() (map[string]bool, error) {
+	templatedPolicyNamesMutex.Lock()
+	defer templatedPolicyNamesMutex.Unlock()
+	if templatedPolicyNames != nil && time.Since(templatedPolicyNamesCheckedAt) < templatedPoliciesCacheTimeout {
+		return templatedPolicyNames, nil
+	}
+	templatedPolicies, _, err := aclClient.TemplatedPolicyList(&consulApi.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can not read templated policies, Consul 1.17 or later is required: %w", err)
+	}
+	templatedPolicyNames = map[string]bool{}
+	for name := range templatedPolicies {
+		templatedPolicyNames[name] = true
+	}
+	templatedPolicyNamesCheckedAt = time.Now()
+	return templatedPolicyNames, nil
+}
+
+func getRoleTemplateNames(role consulApi.ACLRole) []string {
+	var templateNames []string
+	for _, templatedPolicy := range role.TemplatedPolicies {
+		templateNames = append(templateNames, templatedPolicy.TemplateName)
+	}
+	return templateNames
+}
+
+func formatTemplatedPolicy(templateName string, variables *consulApi.ACLTemplatedPolicyVariables) string {
+	if variables == nil || variables.Name == "" {
+		return fmt.Sprintf("templated_policy = %q", templateName)
+	}
+	return fmt.Sprintf("templated_policy = %q { name = %q }", templateName, variables.Name)
+}
+
+func sortedKeys(values map[string]bool) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkBindType returns an error if the bind type of binding rule is not supported. Other bind types of Consul,
+// for example "service", are not bound to the namespace, so they are not allowed.
+func checkBindType(bindType string) error {
+	switch consulApi.BindingRuleBindType(bindType) {
+	case "", consulApi.BindingRuleBindTypeRole, consulApi.BindingRuleBindTypeTemplatedPolicy:
+		return nil
+	}
+	return fmt.Errorf("bind type [%s] is not supported, use %s or %s", bindType,
+		consulApi.BindingRuleBindTypeRole, consulApi.BindingRuleBindTypeTemplatedPolicy)
+}
+
+// templatedPolicyUsage is a templated policy of a role or a binding rule of ACL configuration. Rules of the templated
+// policy are checked by guardrails and approval as a policy which name identifies the usage.
+type templatedPolicyUsage struct {
+	policy ACLPolicyAdapter
+	// templatedPolicy is the templated policy with its variables in the form of rule
+	templatedPolicy string
+	// role is the name of role in ACL configuration, it is empty for a binding rule
+	role string
+	// bindRule is the index of binding rule in ACL configuration
+	bindRule int
+}
+
+type templatedPolicyUsages []templatedPolicyUsage
+
+// renderTemplatedPolicies returns templated policies of roles and binding rules with their rules.
+// Errors of rendering are returned by the names of usages, and a network error is returned as error.
+// Variables of binding rules are rendered for the service account of binding rule in the namespace.
+func renderTemplatedPolicies(aclConfig *ACLConfig, namespace string) (templatedPolicyUsages, map[string]string, error) {
+	var usages templatedPolicyUsages
+	errs := map[string]string{}
+	var netErr error
+	add := func(templateName string, variables *consulApi.ACLTemplatedPolicyVariables, owner string, usage templatedPolicyUsage) {
+		usage.templatedPolicy = formatTemplatedPolicy(templateName, variables)
+		usage.policy.Name = fmt.Sprintf("%s of %s", usage.templatedPolicy, owner)
+		rules, err := renderTemplatedPolicy(templateName, variables)
+		if err != nil {
+			errs[usage.policy.Name] = fmt.Sprintf("can not render templated policy: %s", err)
+			if _, ok := err.(net.Error); ok {
+				netErr = err
+			}
+		}
+		usage.policy.Rules = rules
+		usages = append(usages, usage)
+	}
+	for _, role := range aclConfig.Roles {
+		for _, templatedPolicy := range role.TemplatedPolicies {
+			add(templatedPolicy.TemplateName, templatedPolicy.TemplateVariables, fmt.Sprintf("role %s", role.Name),
+				templatedPolicyUsage{role: role.Name})
+		}
+	}
+	for i, bindRule := range aclConfig.BindRules {
+		if bindRule.BindType == string(consulApi.BindingRuleBindTypeTemplatedPolicy) {
+			variables := bindRule.BindVars
+			if variables != nil {
+				variables = &consulApi.ACLTemplatedPolicyVariables{Name: strings.NewReplacer(
+					"${serviceaccount.name}", bindRule.ServiceAccountName,
+					"${serviceaccount.namespace}", namespace).Replace(variables.Name)}
+			}
+			add(bindRule.BindName, variables, fmt.Sprintf("binding rule for %s", bindRule.ServiceAccountName),
+				templatedPolicyUsage{bindRule: i})
+		}
+	}
+	return usages, errs, netErr
+}
+
+// renderTemplatedPolicy returns rules which the templated policy grants with the variables
+func renderTemplatedPolicy(templateName string, variables *consulApi.ACLTemplatedPolicyVariables) (string, error) {
+	key := formatTemplatedPolicy(templateName, variables)
+	renderedTemplatedPoliciesMutex.Lock()
+	defer renderedTemplatedPoliciesMutex.Unlock()
+	if time.Since(renderedTemplatedPoliciesCheckedAt) >= templatedPoliciesCacheTimeout {
+		renderedTemplatedPolicies = map[string]string{}
+		renderedTemplatedPoliciesCheckedAt = time.Now()
+	}
+	if rules, ok := renderedTemplatedPolicies[key]; ok {
+		return rules, nil
+	}
+	policy, _, err := aclClient.TemplatedPolicyPreview(&consulApi.ACLTemplatedPolicy{
+		TemplateName:      templateName,
+		TemplateVariables: variables,
+	}, &consulApi.WriteOptions{})
+	if err != nil {
+		return "", err
+	}
+	renderedTemplatedPolicies[key] = policy.Rules
+	return policy.Rules, nil
+}
+
+// policies returns templated policies as policies
+func (usages templatedPolicyUsages) policies() []ACLPolicyAdapter {
+	var policies []ACLPolicyAdapter
+	for _, usage := range usages {
+		policies = append(policies, usage.policy)
+	}
+	return policies
+}
+
+// split separates reasons of templated policies from reasons of policies and assigns them to roles and binding rules
+// which use the templated policies
+func (usages templatedPolicyUsages) split(reasons map[string]string) (map[string]string, map[string]string, map[int]string) {
+	policies := map[string]string{}
+	for name, reason := range reasons {
+		policies[name] = reason
+	}
+	roles := map[string]string{}
+	bindRules := map[int]string{}
+	for _, usage := range usages {
+		reason, ok := reasons[usage.policy.Name]
+		if !ok {
+			continue
+		}
+		delete(policies, usage.policy.Name)
+		reason = fmt.Sprintf("%s: %s", usage.templatedPolicy, reason)
+		if usage.role != "" {
+			roles[usage.role] = reason
+		} else {
+			bindRules[usage.bindRule] = reason
+		}
+	}
+	return policies, roles, bindRules
+}
@@ -15,13 +15,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/util"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"net/http"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -29,16 +34,23 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	consulaclv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1"
 	qubershiporgv1 "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
 	"github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/controllers"
 	//+kubebuilder:scaffold:imports
 )
 
+const (
+	aclBootstrapRetryPeriod = 5 * time.Second
+	webhookCertDir          = "/tmp/k8s-webhook-server/serving-certs"
+)
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -47,7 +59,10 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+
 	utilruntime.Must(qubershiporgv1.AddToScheme(scheme))
+	utilruntime.Must(consulaclv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -79,10 +94,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	maxConcurrentReconciles, err := getMaxConcurrentReconciles()
+	if err != nil {
+		setupLog.Error(err, "unable to get maximum number of concurrent reconciles")
+		os.Exit(1)
+	}
+
 	mgrOptions := ctrl.Options{
 		Scheme:                  scheme,
 		MetricsBindAddress:      metricsAddr,
 		Port:                    9443,
+		CertDir:                 webhookCertDir,
 		HealthProbeBindAddress:  probeAddr,
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionID:        fmt.Sprintf("consulacls.%s.netcracker.com", ownNamespace),
@@ -97,54 +119,161 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.ConsulACLReconciler{
-		Client:           mgr.GetClient(),
-		Scheme:           mgr.GetScheme(),
-		ResourceVersions: map[string]string{},
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ConsulACL")
+	// the cache of the manager is not started yet, and secrets and config maps of the operator should not be cached anyway,
+	// so the direct client is used for them
+	directClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
 		os.Exit(1)
 	}
 
-	customScheme := runtime.NewScheme()
+	if err = controllers.SetupConsulClient(); err != nil {
+		setupLog.Error(err, "unable to create Consul client")
+		os.Exit(1)
+	}
 
-	utilruntime.Must(clientgoscheme.AddToScheme(customScheme))
+	ctx := ctrl.SetupSignalHandler()
+	if os.Getenv("ACL_BOOTSTRAP_ENABLED") == "true" {
+		if err = bootstrapACLSystem(ctx, directClient, ownNamespace); err != nil {
+			setupLog.Error(err, "unable to bootstrap Consul ACL system")
+			os.Exit(1)
+		}
+	}
 
-	GroupVersion := schema.GroupVersion{
-		Group:   "netcracker.com",
-		Version: "v1alpha1",
+	if os.Getenv("OPERATOR_TOKEN_ENABLED") == "true" {
+		if err = setupOperatorToken(ctx, mgr, directClient, ownNamespace); err != nil {
+			setupLog.Error(err, "unable to set up operator token")
+			os.Exit(1)
+		}
 	}
-	SchemeBuilder := runtime.NewSchemeBuilder(func(scheme *runtime.Scheme) error {
-		scheme.AddKnownTypes(GroupVersion, &qubershiporgv1.ConsulACL{})
-		return nil
-	})
 
-	AddToScheme := SchemeBuilder.AddToScheme
-	err = AddToScheme(customScheme)
-	if err != nil {
-		panic(err)
+	// the conversion webhook of ConsulACL is served with the certificate generated by the operator when the service name
+	// is specified, otherwise the certificate and the custom resource definition are configured externally
+	webhookServiceName := os.Getenv("WEBHOOK_SERVICE_NAME")
+	conversionManager := controllers.NewConversionManager(directClient, mgr.GetAPIReader(), ownNamespace, webhookServiceName)
+	if err = conversionManager.SetupCertificate(ctx, webhookCertDir); err != nil {
+		setupLog.Error(err, "unable to set up webhook certificate")
+		os.Exit(1)
 	}
+
+	if err = controllers.SetupAudit(directClient, ownNamespace); err != nil {
+		setupLog.Error(err, "unable to set up audit")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.ConsulACLReconciler{
-		Client:           mgr.GetClient(),
-		Scheme:           customScheme,
-		ResourceVersions: map[string]string{},
+		Client:                  mgr.GetClient(),
+		APIReader:               mgr.GetAPIReader(),
+		Scheme:                  mgr.GetScheme(),
+		ResourceVersions:        map[string]string{},
+		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ConsulACL")
 		os.Exit(1)
 	}
+	if err = (&controllers.ClusterConsulACLReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterConsulACL")
+		os.Exit(1)
+	}
+	if err = (&controllers.ConsulIntentionReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConsulIntention")
+		os.Exit(1)
+	}
+	if err = (&controllers.ConsulConfigEntryReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConsulConfigEntry")
+		os.Exit(1)
+	}
+	if err = (&controllers.ConsulKVReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConsulKV")
+		os.Exit(1)
+	}
+	if err = (&controllers.ConsulExternalServiceReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConsulExternalService")
+		os.Exit(1)
+	}
+	if os.Getenv("SERVICE_ACCOUNT_BINDING_ENABLED") == "true" {
+		if err = (&controllers.ServiceAccountReconciler{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ServiceAccount")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.NamespaceReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		WatchNamespaces: getNamespaceList(watchNamespaces),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Namespace")
+		os.Exit(1)
+	}
+	if os.Getenv("ORPHAN_GC_ENABLED") == "true" {
+		orphanCollector, err := controllers.NewOrphanCollector(mgr.GetAPIReader(), getNamespaceList(watchNamespaces))
+		if err != nil {
+			setupLog.Error(err, "unable to create orphan collector")
+			os.Exit(1)
+		}
+		if err = mgr.Add(orphanCollector); err != nil {
+			setupLog.Error(err, "unable to set up orphan collector")
+			os.Exit(1)
+		}
+	}
+
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" || webhookServiceName != "" {
+		if err = controllers.SetupConversionWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ConsulACL conversion")
+			os.Exit(1)
+		}
+		if err = mgr.Add(conversionManager); err != nil {
+			setupLog.Error(err, "unable to set up conversion manager")
+			os.Exit(1)
+		}
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&controllers.ConsulACLValidator{
+			Client: mgr.GetClient(),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ConsulACL")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("consul-acl", controllers.CheckACLSystemReady); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("consul-acl-write", controllers.CheckTokenPermissionsReady); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddMetricsExtraHandler("/health", http.HandlerFunc(controllers.HealthReportHandler)); err != nil {
+		setupLog.Error(err, "unable to set up health report")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting ConsulACL manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running ConsulACL manager")
 		os.Exit(1)
 	}
@@ -164,6 +293,54 @@ func getWatchNamespace() (string, error) {
 	return ns, nil
 }
 
+// bootstrapACLSystem waits until Consul is available and bootstraps its ACL system if it is not bootstrapped yet
+func bootstrapACLSystem(ctx context.Context, directClient client.Client, ownNamespace string) error {
+	bootstrapper := controllers.NewACLSystemBootstrapper(directClient, ownNamespace)
+	return wait.PollImmediateUntil(aclBootstrapRetryPeriod, func() (bool, error) {
+		return bootstrapper.Bootstrap(ctx)
+	}, ctx.Done())
+}
+
+// setupOperatorToken replaces the bootstrap token with the operator token before controllers are started
+// and registers rotation and reload of the operator token in the Manager
+func setupOperatorToken(ctx context.Context, mgr ctrl.Manager, directClient client.Client, ownNamespace string) error {
+	tokenManager, err := controllers.NewOperatorTokenManager(directClient, ownNamespace)
+	if err != nil {
+		return err
+	}
+	if err = tokenManager.Bootstrap(ctx); err != nil {
+		return err
+	}
+	if err = mgr.Add(tokenManager); err != nil {
+		return err
+	}
+	return mgr.Add(tokenManager.Reloader())
+}
+
+// getMaxConcurrentReconciles returns the number of ConsulACL resources which can be reconciled in parallel
+func getMaxConcurrentReconciles() (int, error) {
+	value, found := os.LookupEnv("MAX_CONCURRENT_RECONCILES")
+	if !found || value == "" {
+		return 1, nil
+	}
+	maxConcurrentReconciles, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if maxConcurrentReconciles < 1 {
+		return 0, fmt.Errorf("MAX_CONCURRENT_RECONCILES must be positive, but %d is specified", maxConcurrentReconciles)
+	}
+	return maxConcurrentReconciles, nil
+}
+
+// getNamespaceList returns the list of watched namespaces, empty list means all namespaces
+func getNamespaceList(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return strings.Split(namespace, ",")
+}
+
 func configureMgrNamespaces(mgrOptions *ctrl.Options, namespace string, ownNamespace string) {
 	if namespace == "" || namespace == ownNamespace {
 		mgrOptions.Namespace = namespace
@@ -16,37 +16,39 @@ package util
 
 import (
 	"context"
-	consulacl "github.com/Netcracker/consul-acl-configurator/consul-acl-configurator-operator/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-type CustomResourceUpdater struct {
+// CustomResourceUpdater updates the custom resource or its status and retries on conflicts.
+// The custom resource is read again before each attempt, so updates are applied to its latest version.
+type CustomResourceUpdater[T client.Object] struct {
 	client    client.Client
 	name      string
 	namespace string
 }
 
-func NewCustomResourceUpdater(client client.Client, cr *consulacl.ConsulACL) CustomResourceUpdater {
-	return CustomResourceUpdater{
+func NewCustomResourceUpdater[T client.Object](client client.Client, cr T) CustomResourceUpdater[T] {
+	return CustomResourceUpdater[T]{
 		client:    client,
-		name:      cr.Name,
-		namespace: cr.Namespace,
+		name:      cr.GetName(),
+		namespace: cr.GetNamespace(),
 	}
 }
 
-func (cru CustomResourceUpdater) UpdateWithRetry(updateFunc func(*consulacl.ConsulACL)) error {
+func (cru CustomResourceUpdater[T]) UpdateWithRetry(updateFunc func(T)) error {
 	return cru.updateWithRetry(updateFunc, cru.client)
 }
 
-func (cru CustomResourceUpdater) UpdateStatusWithRetry(statusUpdateFunc func(*consulacl.ConsulACL)) error {
+func (cru CustomResourceUpdater[T]) UpdateStatusWithRetry(statusUpdateFunc func(T)) error {
 	return cru.updateWithRetry(statusUpdateFunc, cru.client.Status())
 }
 
-func (cru CustomResourceUpdater) updateWithRetry(updateFunc func(*consulacl.ConsulACL), writer client.StatusWriter) error {
+func (cru CustomResourceUpdater[T]) updateWithRetry(updateFunc func(T), writer client.StatusWriter) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		instance := &consulacl.ConsulACL{}
+		instance := cru.newObject()
 		if err := cru.client.Get(context.TODO(),
 			types.NamespacedName{Name: cru.name, Namespace: cru.namespace}, instance); err != nil {
 			return err
@@ -55,3 +57,9 @@ func (cru CustomResourceUpdater) updateWithRetry(updateFunc func(*consulacl.Cons
 		return writer.Update(context.TODO(), instance)
 	})
 }
+
+// newObject returns an empty custom resource, so fields of the previous attempt are not kept
+func (cru CustomResourceUpdater[T]) newObject() T {
+	var object T
+	return reflect.New(reflect.TypeOf(object).Elem()).Interface().(T)
+}
@@ -39,7 +39,7 @@ import (
 
 var deploymentRes = schema.GroupVersionResource{
 	Group:    getEnv("API_GROUP", "netcracker.com"),
-	Version:  "v1alpha1",
+	Version:  "v1",
 	Resource: "consulacls"}
 
 var kubeconfig = new(string)
@@ -175,16 +175,10 @@ func changeReconcileSpecField() bool {
 		return false
 	}
 	for _, obj := range objs.Items {
-		aclSpecField, ok := (obj.Object["spec"]).(map[string]interface{})["acl"]
+		aclSpec, ok := (obj.Object["spec"]).(map[string]interface{})
 		if !ok {
 			result = false
-			log.Printf("Error: Can not convert acl spec field to map[string]interface{} type for object -%s ", obj)
-			continue
-		}
-		aclSpec, ok := (aclSpecField).(map[string]interface{})
-		if !ok {
-			result = false
-			log.Printf("Error: Can not convert acl.name spec field to map[string]interface{} type for object - %s", obj)
+			log.Printf("Error: Can not convert spec field to map[string]interface{} type for object - %s", obj)
 			continue
 		}
 		aclSpec["commonReconcile"] = message